@@ -1,4 +1,4 @@
-use actix_web::{delete, get, post, put, web, App, HttpResponse, HttpServer, Responder};
+use actix_web::{delete, get, http::header, post, put, web, App, HttpRequest, HttpResponse, HttpServer, Responder};
 use chrono::{DateTime, Utc};
 use deadpool_postgres::{Config as DeadpoolConfig, ManagerConfig, Pool, PoolConfig, RecyclingMethod, Runtime};
 use serde::{Deserialize, Serialize};
@@ -410,6 +410,54 @@ async fn delete_user(data: web::Data<AppState>, path: web::Path<i32>) -> impl Re
     }
 }
 
+// ---------------------------------------------------------------------------
+// Router misses
+// ---------------------------------------------------------------------------
+
+/// Method and path of every route registered in `main`, for the Allow
+/// header of a 405. Keep in sync with the `.service(...)` list there.
+const ROUTES: &[(&str, &str)] = &[
+    ("GET", "/"),
+    ("GET", "/json"),
+    ("GET", "/db"),
+    ("GET", "/queries"),
+    ("GET", "/users"),
+    ("GET", "/users/{id}"),
+    ("POST", "/users"),
+    ("PUT", "/users/{id}"),
+    ("DELETE", "/users/{id}"),
+];
+
+/// Reports whether `path` fits a route pattern whose `{name}` segments
+/// match any single non-empty segment.
+fn match_route(pattern: &str, path: &str) -> bool {
+    let ps: Vec<&str> = pattern.split('/').collect();
+    let segs: Vec<&str> = path.split('/').collect();
+    ps.len() == segs.len()
+        && ps
+            .iter()
+            .zip(&segs)
+            .all(|(p, s)| p == s || (p.starts_with('{') && !s.is_empty()))
+}
+
+/// Default service: answers every request no route matched with the JSON
+/// error envelope shared by every implementation, instead of actix's empty
+/// 404. Each macro route is its own resource, so a known path hit with the
+/// wrong method lands here too and is answered as a 405.
+async fn not_found(req: HttpRequest) -> HttpResponse {
+    let allowed: Vec<&str> = ROUTES
+        .iter()
+        .filter(|(_, pattern)| match_route(pattern, req.path()))
+        .map(|(method, _)| *method)
+        .collect();
+    if allowed.is_empty() {
+        return HttpResponse::NotFound().json(serde_json::json!({ "error": "Not found" }));
+    }
+    HttpResponse::MethodNotAllowed()
+        .insert_header((header::ALLOW, allowed.join(", ")))
+        .json(serde_json::json!({ "error": "Method not allowed" }))
+}
+
 // ---------------------------------------------------------------------------
 // Pool construction
 // ---------------------------------------------------------------------------
@@ -540,6 +588,7 @@ async fn main() -> std::io::Result<()> {
             .service(create_user)
             .service(update_user)
             .service(delete_user)
+            .default_service(web::to(not_found))
     })
     // Use all available logical CPUs for maximum throughput.
     .workers(num_cpus())
@@ -0,0 +1,29 @@
+module api-atreugo
+
+go 1.22
+
+require (
+	github.com/lib/pq v1.10.9
+	github.com/savsgio/atreugo/v11 v11.12.0
+	go.uber.org/automaxprocs v1.6.0
+	problem v0.0.0-00010101000000-000000000000
+	tlsconfig v0.0.0-00010101000000-000000000000
+	tuning v0.0.0-00010101000000-000000000000
+)
+
+require (
+	github.com/andybalholm/brotli v1.0.5 // indirect
+	github.com/fasthttp/router v1.4.22 // indirect
+	github.com/klauspost/compress v1.17.0 // indirect
+	github.com/savsgio/gotils v0.0.0-20230208104028-c358bd845dee // indirect
+	github.com/valyala/bytebufferpool v1.0.0 // indirect
+	github.com/valyala/fasthttp v1.51.0 // indirect
+	github.com/valyala/tcplisten v1.0.0 // indirect
+	golang.org/x/sys v0.13.0 // indirect
+)
+
+replace problem => ../problem
+
+replace tlsconfig => ../tlsconfig
+
+replace tuning => ../tuning
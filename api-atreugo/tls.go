@@ -0,0 +1,26 @@
+package main
+
+import (
+	"crypto/tls"
+	"net"
+
+	"github.com/savsgio/atreugo/v11"
+)
+
+// ---------------------------------------------------------------------------
+// TLS listener (TLS_CERT, TLS_KEY; config from the shared tlsconfig package)
+// ---------------------------------------------------------------------------
+
+// listenAndServe serves srv on addr, over TLS when tlsConfig is set.
+// Atreugo only takes certificate files, so the listener is wrapped here to
+// keep SIGHUP reloads; fasthttp speaks HTTP/1.1 only.
+func listenAndServe(srv *atreugo.Atreugo, addr string, tlsConfig *tls.Config) error {
+	if tlsConfig == nil {
+		return srv.ListenAndServe()
+	}
+	ln, err := net.Listen("tcp4", addr)
+	if err != nil {
+		return err
+	}
+	return srv.Serve(tls.NewListener(ln, tlsConfig))
+}
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/beego/beego/v2/server/web"
+	beecontext "github.com/beego/beego/v2/server/web/context"
+
+	"problem"
+)
+
+// ---------------------------------------------------------------------------
+// Controllers
+// ---------------------------------------------------------------------------
+
+const (
+	queryRandomUser  = `SELECT id, name, email, age, created_at FROM users ORDER BY RANDOM() LIMIT 1`
+	queryRandomUsers = `SELECT id, name, email, age, created_at FROM users ORDER BY RANDOM() LIMIT $1`
+	queryListUsers   = `SELECT id, name, email, age, created_at FROM users ORDER BY id`
+	queryPageUsers   = `SELECT id, name, email, age, created_at FROM users ORDER BY id LIMIT $1 OFFSET $2`
+	queryCountUsers  = `SELECT COUNT(*)::int FROM users`
+	queryGetUser     = `SELECT id, name, email, age, created_at FROM users WHERE id = $1`
+	queryCreateUser  = `
+		INSERT INTO users (name, email, age)
+		VALUES ($1, $2, $3)
+		RETURNING id, name, email, age, created_at`
+	// COALESCE updates only the provided fields, the same SQL as api-gin.
+	queryUpdateUser = `
+		UPDATE users
+		SET name  = COALESCE($1, name),
+		    email = COALESCE($2, email),
+		    age   = COALESCE($3, age)
+		WHERE id = $4
+		RETURNING id, name, email, age, created_at`
+	queryDeleteUser = `DELETE FROM users WHERE id = $1 RETURNING id`
+)
+
+// baseController adds the JSON helpers shared by every controller.
+type baseController struct {
+	web.Controller
+}
+
+// respond writes v as JSON with the given status.
+func (c *baseController) respond(status int, v any) {
+	c.Ctx.Output.SetStatus(status)
+	c.Data["json"] = v
+	if err := c.ServeJSON(); err != nil {
+		log.Printf("write response: %v", err)
+	}
+}
+
+// errorJSON writes the problem details envelope shared by every Go
+// implementation; detail, when non-empty, is appended to msg.
+func (c *baseController) errorJSON(status int, msg, detail string) {
+	writeProblem(c.Ctx, problem.New(status, c.Ctx.Request.URL.Path, msg, detail))
+}
+
+// writeProblem writes d straight to the output, bypassing ServeJSON so the
+// problem+json content type survives.
+func writeProblem(ctx *beecontext.Context, d problem.Details) {
+	ctx.Output.SetStatus(d.Status)
+	ctx.Output.Header("Content-Type", problem.ContentType)
+	if err := ctx.Output.Body(d.JSON()); err != nil {
+		log.Printf("write response: %v", err)
+	}
+}
+
+// dbError maps a query error onto the JSON envelope: sql.ErrNoRows is
+// notFound (404), a duplicate email 409, anything else 500.
+func (c *baseController) dbError(err error, notFound string) {
+	switch {
+	case err == sql.ErrNoRows:
+		c.errorJSON(http.StatusNotFound, notFound, "")
+	case isUniqueViolation(err):
+		c.errorJSON(http.StatusConflict, "Email already in use", "")
+	default:
+		c.errorJSON(http.StatusInternalServerError, "Database error", err.Error())
+	}
+}
+
+// MainController serves the DB-free routes.
+type MainController struct {
+	baseController
+}
+
+// GET /
+func (c *MainController) Root() {
+	c.respond(http.StatusOK, map[string]string{
+		"message":   "Beego API",
+		"framework": "beego",
+		"runtime":   "go",
+	})
+}
+
+// GET /json
+func (c *MainController) JSON() {
+	c.respond(http.StatusOK, map[string]string{
+		"message":   "Hello, World!",
+		"framework": "beego",
+	})
+}
+
+// ErrorController answers Beego's router misses with the JSON envelope
+// instead of its HTML error pages.
+type ErrorController struct {
+	baseController
+}
+
+// Error404 answers unregistered paths. Beego looks routes up per method, so
+// a known path hit with the wrong method lands here too; it is told apart
+// by matching the path under the other methods, which only costs on a miss.
+func (c *ErrorController) Error404() {
+	if allowed := allowedMethods(c.Ctx.Request); len(allowed) > 0 {
+		c.Ctx.Output.Header("Allow", strings.Join(allowed, ", "))
+		c.errorJSON(http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+	c.errorJSON(http.StatusNotFound, "Not found", "")
+}
+
+// Error405 answers a method Beego does not route at all, such as TRACE.
+func (c *ErrorController) Error405() {
+	c.Ctx.Output.Header("Allow", strings.Join(allowedMethods(c.Ctx.Request), ", "))
+	c.errorJSON(http.StatusMethodNotAllowed, "Method not allowed", "")
+}
+
+// allowedMethods lists the methods routed for the path of r.
+func allowedMethods(r *http.Request) []string {
+	var allowed []string
+	for _, m := range []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete} {
+		probe := r.Clone(r.Context())
+		probe.Method = m
+		ctx := beecontext.NewContext()
+		ctx.Reset(nil, probe)
+		if _, ok := web.BeeApp.Handlers.FindRouter(ctx); ok {
+			allowed = append(allowed, m)
+		}
+	}
+	return allowed
+}
+
+// UserController serves /db, /queries and the /users CRUD.
+type UserController struct {
+	baseController
+}
+
+// GET /db — single random user from the database
+func (c *UserController) DB() {
+	user, err := scanUser(db.QueryRowContext(c.Ctx.Request.Context(), queryRandomUser).Scan)
+	if err != nil {
+		c.dbError(err, "No users found")
+		return
+	}
+	c.respond(http.StatusOK, user)
+}
+
+// GET /queries?count=N — N random users in a single query (1-500, default 1)
+func (c *UserController) Queries() {
+	count := parseCount(c.Ctx.Input.Query("count"))
+
+	rows, err := db.QueryContext(c.Ctx.Request.Context(), queryRandomUsers, count)
+	if err != nil {
+		c.dbError(err, "No users found")
+		return
+	}
+	users, err := collectUsers(rows, count)
+	if err != nil {
+		c.dbError(err, "No users found")
+		return
+	}
+	c.respond(http.StatusOK, users)
+}
+
+// GET /users — all users ordered by id
+// Optional: ?limit=N (1-100) and ?offset=N (>=0) for pagination.
+func (c *UserController) List() {
+	ctx := c.Ctx.Request.Context()
+
+	limitStr := c.Ctx.Input.Query("limit")
+	if limitStr == "" {
+		rows, err := db.QueryContext(ctx, queryListUsers)
+		if err != nil {
+			c.dbError(err, "No users found")
+			return
+		}
+		users, err := collectUsers(rows, 0)
+		if err != nil {
+			c.dbError(err, "No users found")
+			return
+		}
+		c.respond(http.StatusOK, users)
+		return
+	}
+
+	limit := 20
+	if n, err := strconv.Atoi(limitStr); err == nil {
+		limit = n
+	}
+	limit = min(max(limit, 1), 100)
+	offset := 0
+	if n, err := strconv.Atoi(c.Ctx.Input.Query("offset")); err == nil && n > 0 {
+		offset = n
+	}
+
+	// Run COUNT and the paginated SELECT concurrently.
+	type countResult struct {
+		total int
+		err   error
+	}
+	countCh := make(chan countResult, 1)
+	go func() {
+		var total int
+		err := db.QueryRowContext(ctx, queryCountUsers).Scan(&total)
+		countCh <- countResult{total, err}
+	}()
+
+	var users []User
+	rows, err := db.QueryContext(ctx, queryPageUsers, limit, offset)
+	if err == nil {
+		users, err = collectUsers(rows, limit)
+	}
+	cr := <-countCh
+	if cr.err != nil {
+		c.dbError(cr.err, "No users found")
+		return
+	}
+	if err != nil {
+		c.dbError(err, "No users found")
+		return
+	}
+
+	c.respond(http.StatusOK, PaginatedUsers{Data: users, Total: cr.total, Limit: limit, Offset: offset})
+}
+
+// GET /users/:id — single user by ID
+func (c *UserController) Get() {
+	id, ok := parseID(c.Ctx.Input.Param(":id"))
+	if !ok {
+		c.errorJSON(http.StatusBadRequest, "Invalid user ID", "")
+		return
+	}
+
+	user, err := scanUser(db.QueryRowContext(c.Ctx.Request.Context(), queryGetUser, id).Scan)
+	if err != nil {
+		c.dbError(err, "User not found")
+		return
+	}
+	c.respond(http.StatusOK, user)
+}
+
+// POST /users — create a user, respond 201 with the created object
+func (c *UserController) Create() {
+	var req CreateUserRequest
+	if err := json.Unmarshal(c.Ctx.Input.RequestBody, &req); err != nil {
+		c.errorJSON(http.StatusBadRequest, "Invalid JSON body", err.Error())
+		return
+	}
+	if req.Name == "" || req.Email == "" {
+		c.errorJSON(http.StatusBadRequest, "name and email are required", "")
+		return
+	}
+
+	row := db.QueryRowContext(c.Ctx.Request.Context(), queryCreateUser, req.Name, req.Email, req.Age)
+	user, err := scanUser(row.Scan)
+	if err != nil {
+		c.dbError(err, "User not found")
+		return
+	}
+	c.respond(http.StatusCreated, user)
+}
+
+// PUT /users/:id — update an existing user, respond with the updated object
+func (c *UserController) Update() {
+	id, ok := parseID(c.Ctx.Input.Param(":id"))
+	if !ok {
+		c.errorJSON(http.StatusBadRequest, "Invalid user ID", "")
+		return
+	}
+
+	var req UpdateUserRequest
+	if err := json.Unmarshal(c.Ctx.Input.RequestBody, &req); err != nil {
+		c.errorJSON(http.StatusBadRequest, "Invalid JSON body", err.Error())
+		return
+	}
+	if req.Name == nil && req.Email == nil && req.Age == nil {
+		c.errorJSON(http.StatusBadRequest, "At least one field (name, email, age) is required", "")
+		return
+	}
+
+	row := db.QueryRowContext(c.Ctx.Request.Context(), queryUpdateUser, req.Name, req.Email, req.Age, id)
+	user, err := scanUser(row.Scan)
+	if err != nil {
+		c.dbError(err, "User not found")
+		return
+	}
+	c.respond(http.StatusOK, user)
+}
+
+// DELETE /users/:id — remove a user, respond 204 on success
+func (c *UserController) Delete() {
+	id, ok := parseID(c.Ctx.Input.Param(":id"))
+	if !ok {
+		c.errorJSON(http.StatusBadRequest, "Invalid user ID", "")
+		return
+	}
+
+	var deletedID int
+	if err := db.QueryRowContext(c.Ctx.Request.Context(), queryDeleteUser, id).Scan(&deletedID); err != nil {
+		c.dbError(err, "User not found")
+		return
+	}
+	c.Ctx.Output.SetStatus(http.StatusNoContent)
+}
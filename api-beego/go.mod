@@ -0,0 +1,37 @@
+module api-beego
+
+go 1.22
+
+require (
+	github.com/beego/beego/v2 v2.3.4
+	github.com/lib/pq v1.10.9
+	go.uber.org/automaxprocs v1.6.0
+	problem v0.0.0-00010101000000-000000000000
+	tlsconfig v0.0.0-00010101000000-000000000000
+	tuning v0.0.0-00010101000000-000000000000
+)
+
+require (
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/cespare/xxhash/v2 v2.2.0 // indirect
+	github.com/hashicorp/golang-lru v0.5.4 // indirect
+	github.com/kr/text v0.2.0 // indirect
+	github.com/mitchellh/mapstructure v1.5.0 // indirect
+	github.com/prometheus/client_golang v1.19.0 // indirect
+	github.com/prometheus/client_model v0.5.0 // indirect
+	github.com/prometheus/common v0.48.0 // indirect
+	github.com/prometheus/procfs v0.12.0 // indirect
+	github.com/shiena/ansicolor v0.0.0-20200904210342-c7312218db18 // indirect
+	golang.org/x/crypto v0.24.0 // indirect
+	golang.org/x/net v0.23.0 // indirect
+	golang.org/x/sys v0.21.0 // indirect
+	golang.org/x/text v0.16.0 // indirect
+	google.golang.org/protobuf v1.34.2 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace problem => ../problem
+
+replace tlsconfig => ../tlsconfig
+
+replace tuning => ../tuning
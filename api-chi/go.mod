@@ -0,0 +1,21 @@
+module api-chi
+
+go 1.22
+
+require (
+	github.com/go-chi/chi/v5 v5.1.0
+	github.com/lib/pq v1.10.9
+)
+
+require (
+	go.uber.org/automaxprocs v1.6.0
+	problem v0.0.0-00010101000000-000000000000
+	tlsconfig v0.0.0-00010101000000-000000000000
+	tuning v0.0.0-00010101000000-000000000000
+)
+
+replace problem => ../problem
+
+replace tlsconfig => ../tlsconfig
+
+replace tuning => ../tuning
@@ -0,0 +1,23 @@
+module api-connect
+
+go 1.22
+
+require (
+	connectrpc.com/connect v1.16.2
+	connectrpc.com/grpcreflect v1.2.0
+	github.com/lib/pq v1.10.9
+	go.uber.org/automaxprocs v1.6.0
+	golang.org/x/net v0.23.0
+	google.golang.org/protobuf v1.34.2
+	problem v0.0.0-00010101000000-000000000000
+	tlsconfig v0.0.0-00010101000000-000000000000
+	tuning v0.0.0-00010101000000-000000000000
+)
+
+require golang.org/x/text v0.14.0 // indirect
+
+replace problem => ../problem
+
+replace tlsconfig => ../tlsconfig
+
+replace tuning => ../tuning
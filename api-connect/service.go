@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"api-connect/userpb"
+)
+
+// ---------------------------------------------------------------------------
+// UserService
+// ---------------------------------------------------------------------------
+
+// Same SQL as the REST implementations, so the only difference measured is
+// the transport and encoding.
+const (
+	queryRandomUser = `SELECT id, name, email, age, created_at FROM users ORDER BY RANDOM() LIMIT 1`
+	queryListUsers  = `SELECT id, name, email, age, created_at FROM users ORDER BY id`
+	queryPageUsers  = `SELECT id, name, email, age, created_at FROM users ORDER BY id LIMIT $1 OFFSET $2`
+	queryCountUsers = `SELECT COUNT(*)::int FROM users`
+	queryGetUser    = `SELECT id, name, email, age, created_at FROM users WHERE id = $1`
+	queryCreateUser = `
+		INSERT INTO users (name, email, age)
+		VALUES ($1, $2, $3)
+		RETURNING id, name, email, age, created_at`
+	// COALESCE updates only the provided fields, the same SQL as api-gin.
+	queryUpdateUser = `
+		UPDATE users
+		SET name  = COALESCE($1, name),
+		    email = COALESCE($2, email),
+		    age   = COALESCE($3, age)
+		WHERE id = $4
+		RETURNING id, name, email, age, created_at`
+	queryDeleteUser = `DELETE FROM users WHERE id = $1 RETURNING id`
+)
+
+type userService struct {
+	db *sql.DB
+}
+
+// scanUser reads one row straight into the protobuf message.
+func scanUser(scan func(...any) error) (*userpb.User, error) {
+	var (
+		u         userpb.User
+		email     string
+		age       sql.NullInt32
+		createdAt time.Time
+	)
+	if err := scan(&u.Id, &u.Name, &email, &age, &createdAt); err != nil {
+		return nil, err
+	}
+	u.Email = &email
+	if age.Valid {
+		u.Age = &age.Int32
+	}
+	u.CreatedAt = timestamppb.New(createdAt)
+	return &u, nil
+}
+
+// invalidArgument is the connect counterpart of a 400 response.
+func invalidArgument(msg string) error {
+	return connect.NewError(connect.CodeInvalidArgument, errors.New(msg))
+}
+
+// connectError maps a query error onto a code: sql.ErrNoRows is notFound,
+// a duplicate email AlreadyExists, anything else Internal.
+func connectError(err error, notFound string) error {
+	switch {
+	case err == sql.ErrNoRows:
+		return connect.NewError(connect.CodeNotFound, errors.New(notFound))
+	case isUniqueViolation(err):
+		return connect.NewError(connect.CodeAlreadyExists, errors.New("Email already in use"))
+	default:
+		return connect.NewError(connect.CodeInternal, fmt.Errorf("Database error: %w", err))
+	}
+}
+
+// isUniqueViolation reports a PostgreSQL unique_violation (SQLSTATE 23505).
+func isUniqueViolation(err error) bool {
+	type hasSQLState interface{ SQLState() string }
+	e, ok := err.(hasSQLState)
+	return ok && e.SQLState() == "23505"
+}
+
+func (s *userService) RandomUser(ctx context.Context, _ *connect.Request[emptypb.Empty]) (*connect.Response[userpb.User], error) {
+	user, err := scanUser(s.db.QueryRowContext(ctx, queryRandomUser).Scan)
+	if err != nil {
+		return nil, connectError(err, "No users found")
+	}
+	return connect.NewResponse(user), nil
+}
+
+func (s *userService) GetUser(ctx context.Context, r *connect.Request[userpb.GetUserRequest]) (*connect.Response[userpb.User], error) {
+	req := r.Msg
+	if req.GetId() < 1 {
+		return nil, invalidArgument("Invalid user ID")
+	}
+	user, err := scanUser(s.db.QueryRowContext(ctx, queryGetUser, req.GetId()).Scan)
+	if err != nil {
+		return nil, connectError(err, "User not found")
+	}
+	return connect.NewResponse(user), nil
+}
+
+// ListUsers returns every user when limit is 0, otherwise one page (limit
+// clamped to 1-100) with the total, COUNT and SELECT running concurrently
+// as in the REST handlers.
+func (s *userService) ListUsers(ctx context.Context, r *connect.Request[userpb.ListUsersRequest]) (*connect.Response[userpb.ListUsersResponse], error) {
+	req := r.Msg
+	limit := int(req.GetLimit())
+	offset := max(int(req.GetOffset()), 0)
+
+	if limit == 0 {
+		rows, err := s.db.QueryContext(ctx, queryListUsers)
+		if err != nil {
+			return nil, connectError(err, "No users found")
+		}
+		users, err := collectUsers(rows, 0)
+		if err != nil {
+			return nil, connectError(err, "No users found")
+		}
+		return connect.NewResponse(&userpb.ListUsersResponse{Users: users}), nil
+	}
+	limit = min(max(limit, 1), 100)
+
+	type countResult struct {
+		total int32
+		err   error
+	}
+	countCh := make(chan countResult, 1)
+	go func() {
+		var total int32
+		err := s.db.QueryRowContext(ctx, queryCountUsers).Scan(&total)
+		countCh <- countResult{total, err}
+	}()
+
+	var users []*userpb.User
+	rows, err := s.db.QueryContext(ctx, queryPageUsers, limit, offset)
+	if err == nil {
+		users, err = collectUsers(rows, limit)
+	}
+	cr := <-countCh
+	if cr.err != nil {
+		return nil, connectError(cr.err, "No users found")
+	}
+	if err != nil {
+		return nil, connectError(err, "No users found")
+	}
+	return connect.NewResponse(&userpb.ListUsersResponse{Users: users, Total: cr.total}), nil
+}
+
+// collectUsers drains rows into a slice; sizeHint preallocates it.
+func collectUsers(rows *sql.Rows, sizeHint int) ([]*userpb.User, error) {
+	defer rows.Close()
+	users := make([]*userpb.User, 0, sizeHint)
+	for rows.Next() {
+		user, err := scanUser(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+func (s *userService) CreateUser(ctx context.Context, r *connect.Request[userpb.CreateUserRequest]) (*connect.Response[userpb.User], error) {
+	req := r.Msg
+	if req.GetName() == "" || req.GetEmail() == "" {
+		return nil, invalidArgument("name and email are required")
+	}
+	row := s.db.QueryRowContext(ctx, queryCreateUser, req.GetName(), req.GetEmail(), req.Age)
+	user, err := scanUser(row.Scan)
+	if err != nil {
+		return nil, connectError(err, "User not found")
+	}
+	return connect.NewResponse(user), nil
+}
+
+func (s *userService) UpdateUser(ctx context.Context, r *connect.Request[userpb.UpdateUserRequest]) (*connect.Response[userpb.User], error) {
+	req := r.Msg
+	if req.GetId() < 1 {
+		return nil, invalidArgument("Invalid user ID")
+	}
+	if req.Name == nil && req.Email == nil && req.Age == nil {
+		return nil, invalidArgument("At least one field (name, email, age) is required")
+	}
+	row := s.db.QueryRowContext(ctx, queryUpdateUser, req.Name, req.Email, req.Age, req.GetId())
+	user, err := scanUser(row.Scan)
+	if err != nil {
+		return nil, connectError(err, "User not found")
+	}
+	return connect.NewResponse(user), nil
+}
+
+func (s *userService) DeleteUser(ctx context.Context, r *connect.Request[userpb.DeleteUserRequest]) (*connect.Response[emptypb.Empty], error) {
+	req := r.Msg
+	if req.GetId() < 1 {
+		return nil, invalidArgument("Invalid user ID")
+	}
+	var deletedID int32
+	if err := s.db.QueryRowContext(ctx, queryDeleteUser, req.GetId()).Scan(&deletedID); err != nil {
+		return nil, connectError(err, "User not found")
+	}
+	return connect.NewResponse(&emptypb.Empty{}), nil
+}
@@ -0,0 +1,265 @@
+// ConnectRPC implementation of the benchmark API. The service is the same
+// benchmark.users.v1.UserService that api-grpc and api-gin (GRPC_PORT) serve,
+// reachable here over the Connect, gRPC and gRPC-Web protocols on one port.
+//
+// Regenerate with `go generate ./...` (requires buf, protoc-gen-go and
+// protoc-gen-connect-go on PATH).
+
+// Code generated by protoc-gen-connect-go. DO NOT EDIT.
+//
+// Source: users.proto
+
+package userpbconnect
+
+import (
+	userpb "api-connect/userpb"
+	connect "connectrpc.com/connect"
+	context "context"
+	errors "errors"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	http "net/http"
+	strings "strings"
+)
+
+// This is a compile-time assertion to ensure that this generated file and the connect package are
+// compatible. If you get a compiler error that this constant is not defined, this code was
+// generated with a version of connect newer than the one compiled into your binary. You can fix the
+// problem by either regenerating this code with an older version of connect or updating the connect
+// version compiled into your binary.
+const _ = connect.IsAtLeastVersion1_13_0
+
+const (
+	// UserServiceName is the fully-qualified name of the UserService service.
+	UserServiceName = "benchmark.users.v1.UserService"
+)
+
+// These constants are the fully-qualified names of the RPCs defined in this package. They're
+// exposed at runtime as Spec.Procedure and as the final two segments of the HTTP route.
+//
+// Note that these are different from the fully-qualified method names used by
+// google.golang.org/protobuf/reflect/protoreflect. To convert from these constants to
+// reflection-formatted method names, remove the leading slash and convert the remaining slash to a
+// period.
+const (
+	// UserServiceRandomUserProcedure is the fully-qualified name of the UserService's RandomUser RPC.
+	UserServiceRandomUserProcedure = "/benchmark.users.v1.UserService/RandomUser"
+	// UserServiceGetUserProcedure is the fully-qualified name of the UserService's GetUser RPC.
+	UserServiceGetUserProcedure = "/benchmark.users.v1.UserService/GetUser"
+	// UserServiceListUsersProcedure is the fully-qualified name of the UserService's ListUsers RPC.
+	UserServiceListUsersProcedure = "/benchmark.users.v1.UserService/ListUsers"
+	// UserServiceCreateUserProcedure is the fully-qualified name of the UserService's CreateUser RPC.
+	UserServiceCreateUserProcedure = "/benchmark.users.v1.UserService/CreateUser"
+	// UserServiceUpdateUserProcedure is the fully-qualified name of the UserService's UpdateUser RPC.
+	UserServiceUpdateUserProcedure = "/benchmark.users.v1.UserService/UpdateUser"
+	// UserServiceDeleteUserProcedure is the fully-qualified name of the UserService's DeleteUser RPC.
+	UserServiceDeleteUserProcedure = "/benchmark.users.v1.UserService/DeleteUser"
+)
+
+// These variables are the protoreflect.Descriptor objects for the RPCs defined in this package.
+var (
+	userServiceServiceDescriptor          = userpb.File_users_proto.Services().ByName("UserService")
+	userServiceRandomUserMethodDescriptor = userServiceServiceDescriptor.Methods().ByName("RandomUser")
+	userServiceGetUserMethodDescriptor    = userServiceServiceDescriptor.Methods().ByName("GetUser")
+	userServiceListUsersMethodDescriptor  = userServiceServiceDescriptor.Methods().ByName("ListUsers")
+	userServiceCreateUserMethodDescriptor = userServiceServiceDescriptor.Methods().ByName("CreateUser")
+	userServiceUpdateUserMethodDescriptor = userServiceServiceDescriptor.Methods().ByName("UpdateUser")
+	userServiceDeleteUserMethodDescriptor = userServiceServiceDescriptor.Methods().ByName("DeleteUser")
+)
+
+// UserServiceClient is a client for the benchmark.users.v1.UserService service.
+type UserServiceClient interface {
+	RandomUser(context.Context, *connect.Request[emptypb.Empty]) (*connect.Response[userpb.User], error)
+	GetUser(context.Context, *connect.Request[userpb.GetUserRequest]) (*connect.Response[userpb.User], error)
+	ListUsers(context.Context, *connect.Request[userpb.ListUsersRequest]) (*connect.Response[userpb.ListUsersResponse], error)
+	CreateUser(context.Context, *connect.Request[userpb.CreateUserRequest]) (*connect.Response[userpb.User], error)
+	UpdateUser(context.Context, *connect.Request[userpb.UpdateUserRequest]) (*connect.Response[userpb.User], error)
+	DeleteUser(context.Context, *connect.Request[userpb.DeleteUserRequest]) (*connect.Response[emptypb.Empty], error)
+}
+
+// NewUserServiceClient constructs a client for the benchmark.users.v1.UserService service. By
+// default, it uses the Connect protocol with the binary Protobuf Codec, asks for gzipped responses,
+// and sends uncompressed requests. To use the gRPC or gRPC-Web protocols, supply the
+// connect.WithGRPC() or connect.WithGRPCWeb() options.
+//
+// The URL supplied here should be the base URL for the Connect or gRPC server (for example,
+// http://api.acme.com or https://acme.com/grpc).
+func NewUserServiceClient(httpClient connect.HTTPClient, baseURL string, opts ...connect.ClientOption) UserServiceClient {
+	baseURL = strings.TrimRight(baseURL, "/")
+	return &userServiceClient{
+		randomUser: connect.NewClient[emptypb.Empty, userpb.User](
+			httpClient,
+			baseURL+UserServiceRandomUserProcedure,
+			connect.WithSchema(userServiceRandomUserMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		getUser: connect.NewClient[userpb.GetUserRequest, userpb.User](
+			httpClient,
+			baseURL+UserServiceGetUserProcedure,
+			connect.WithSchema(userServiceGetUserMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		listUsers: connect.NewClient[userpb.ListUsersRequest, userpb.ListUsersResponse](
+			httpClient,
+			baseURL+UserServiceListUsersProcedure,
+			connect.WithSchema(userServiceListUsersMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		createUser: connect.NewClient[userpb.CreateUserRequest, userpb.User](
+			httpClient,
+			baseURL+UserServiceCreateUserProcedure,
+			connect.WithSchema(userServiceCreateUserMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		updateUser: connect.NewClient[userpb.UpdateUserRequest, userpb.User](
+			httpClient,
+			baseURL+UserServiceUpdateUserProcedure,
+			connect.WithSchema(userServiceUpdateUserMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		deleteUser: connect.NewClient[userpb.DeleteUserRequest, emptypb.Empty](
+			httpClient,
+			baseURL+UserServiceDeleteUserProcedure,
+			connect.WithSchema(userServiceDeleteUserMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+	}
+}
+
+// userServiceClient implements UserServiceClient.
+type userServiceClient struct {
+	randomUser *connect.Client[emptypb.Empty, userpb.User]
+	getUser    *connect.Client[userpb.GetUserRequest, userpb.User]
+	listUsers  *connect.Client[userpb.ListUsersRequest, userpb.ListUsersResponse]
+	createUser *connect.Client[userpb.CreateUserRequest, userpb.User]
+	updateUser *connect.Client[userpb.UpdateUserRequest, userpb.User]
+	deleteUser *connect.Client[userpb.DeleteUserRequest, emptypb.Empty]
+}
+
+// RandomUser calls benchmark.users.v1.UserService.RandomUser.
+func (c *userServiceClient) RandomUser(ctx context.Context, req *connect.Request[emptypb.Empty]) (*connect.Response[userpb.User], error) {
+	return c.randomUser.CallUnary(ctx, req)
+}
+
+// GetUser calls benchmark.users.v1.UserService.GetUser.
+func (c *userServiceClient) GetUser(ctx context.Context, req *connect.Request[userpb.GetUserRequest]) (*connect.Response[userpb.User], error) {
+	return c.getUser.CallUnary(ctx, req)
+}
+
+// ListUsers calls benchmark.users.v1.UserService.ListUsers.
+func (c *userServiceClient) ListUsers(ctx context.Context, req *connect.Request[userpb.ListUsersRequest]) (*connect.Response[userpb.ListUsersResponse], error) {
+	return c.listUsers.CallUnary(ctx, req)
+}
+
+// CreateUser calls benchmark.users.v1.UserService.CreateUser.
+func (c *userServiceClient) CreateUser(ctx context.Context, req *connect.Request[userpb.CreateUserRequest]) (*connect.Response[userpb.User], error) {
+	return c.createUser.CallUnary(ctx, req)
+}
+
+// UpdateUser calls benchmark.users.v1.UserService.UpdateUser.
+func (c *userServiceClient) UpdateUser(ctx context.Context, req *connect.Request[userpb.UpdateUserRequest]) (*connect.Response[userpb.User], error) {
+	return c.updateUser.CallUnary(ctx, req)
+}
+
+// DeleteUser calls benchmark.users.v1.UserService.DeleteUser.
+func (c *userServiceClient) DeleteUser(ctx context.Context, req *connect.Request[userpb.DeleteUserRequest]) (*connect.Response[emptypb.Empty], error) {
+	return c.deleteUser.CallUnary(ctx, req)
+}
+
+// UserServiceHandler is an implementation of the benchmark.users.v1.UserService service.
+type UserServiceHandler interface {
+	RandomUser(context.Context, *connect.Request[emptypb.Empty]) (*connect.Response[userpb.User], error)
+	GetUser(context.Context, *connect.Request[userpb.GetUserRequest]) (*connect.Response[userpb.User], error)
+	ListUsers(context.Context, *connect.Request[userpb.ListUsersRequest]) (*connect.Response[userpb.ListUsersResponse], error)
+	CreateUser(context.Context, *connect.Request[userpb.CreateUserRequest]) (*connect.Response[userpb.User], error)
+	UpdateUser(context.Context, *connect.Request[userpb.UpdateUserRequest]) (*connect.Response[userpb.User], error)
+	DeleteUser(context.Context, *connect.Request[userpb.DeleteUserRequest]) (*connect.Response[emptypb.Empty], error)
+}
+
+// NewUserServiceHandler builds an HTTP handler from the service implementation. It returns the path
+// on which to mount the handler and the handler itself.
+//
+// By default, handlers support the Connect, gRPC, and gRPC-Web protocols with the binary Protobuf
+// and JSON codecs. They also support gzip compression.
+func NewUserServiceHandler(svc UserServiceHandler, opts ...connect.HandlerOption) (string, http.Handler) {
+	userServiceRandomUserHandler := connect.NewUnaryHandler(
+		UserServiceRandomUserProcedure,
+		svc.RandomUser,
+		connect.WithSchema(userServiceRandomUserMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	userServiceGetUserHandler := connect.NewUnaryHandler(
+		UserServiceGetUserProcedure,
+		svc.GetUser,
+		connect.WithSchema(userServiceGetUserMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	userServiceListUsersHandler := connect.NewUnaryHandler(
+		UserServiceListUsersProcedure,
+		svc.ListUsers,
+		connect.WithSchema(userServiceListUsersMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	userServiceCreateUserHandler := connect.NewUnaryHandler(
+		UserServiceCreateUserProcedure,
+		svc.CreateUser,
+		connect.WithSchema(userServiceCreateUserMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	userServiceUpdateUserHandler := connect.NewUnaryHandler(
+		UserServiceUpdateUserProcedure,
+		svc.UpdateUser,
+		connect.WithSchema(userServiceUpdateUserMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	userServiceDeleteUserHandler := connect.NewUnaryHandler(
+		UserServiceDeleteUserProcedure,
+		svc.DeleteUser,
+		connect.WithSchema(userServiceDeleteUserMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	return "/benchmark.users.v1.UserService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case UserServiceRandomUserProcedure:
+			userServiceRandomUserHandler.ServeHTTP(w, r)
+		case UserServiceGetUserProcedure:
+			userServiceGetUserHandler.ServeHTTP(w, r)
+		case UserServiceListUsersProcedure:
+			userServiceListUsersHandler.ServeHTTP(w, r)
+		case UserServiceCreateUserProcedure:
+			userServiceCreateUserHandler.ServeHTTP(w, r)
+		case UserServiceUpdateUserProcedure:
+			userServiceUpdateUserHandler.ServeHTTP(w, r)
+		case UserServiceDeleteUserProcedure:
+			userServiceDeleteUserHandler.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// UnimplementedUserServiceHandler returns CodeUnimplemented from all methods.
+type UnimplementedUserServiceHandler struct{}
+
+func (UnimplementedUserServiceHandler) RandomUser(context.Context, *connect.Request[emptypb.Empty]) (*connect.Response[userpb.User], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("benchmark.users.v1.UserService.RandomUser is not implemented"))
+}
+
+func (UnimplementedUserServiceHandler) GetUser(context.Context, *connect.Request[userpb.GetUserRequest]) (*connect.Response[userpb.User], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("benchmark.users.v1.UserService.GetUser is not implemented"))
+}
+
+func (UnimplementedUserServiceHandler) ListUsers(context.Context, *connect.Request[userpb.ListUsersRequest]) (*connect.Response[userpb.ListUsersResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("benchmark.users.v1.UserService.ListUsers is not implemented"))
+}
+
+func (UnimplementedUserServiceHandler) CreateUser(context.Context, *connect.Request[userpb.CreateUserRequest]) (*connect.Response[userpb.User], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("benchmark.users.v1.UserService.CreateUser is not implemented"))
+}
+
+func (UnimplementedUserServiceHandler) UpdateUser(context.Context, *connect.Request[userpb.UpdateUserRequest]) (*connect.Response[userpb.User], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("benchmark.users.v1.UserService.UpdateUser is not implemented"))
+}
+
+func (UnimplementedUserServiceHandler) DeleteUser(context.Context, *connect.Request[userpb.DeleteUserRequest]) (*connect.Response[emptypb.Empty], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("benchmark.users.v1.UserService.DeleteUser is not implemented"))
+}
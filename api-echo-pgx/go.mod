@@ -0,0 +1,34 @@
+module api-echo-pgx
+
+go 1.22
+
+require (
+	github.com/jackc/pgx/v5 v5.7.1
+	github.com/labstack/echo/v4 v4.12.0
+	go.uber.org/automaxprocs v1.6.0
+	problem v0.0.0-00010101000000-000000000000
+	tlsconfig v0.0.0-00010101000000-000000000000
+	tuning v0.0.0-00010101000000-000000000000
+)
+
+require (
+	github.com/jackc/pgpassfile v1.0.0 // indirect
+	github.com/jackc/pgservicefile v0.0.0-20240606120523-5a60cdf6a761 // indirect
+	github.com/jackc/puddle/v2 v2.2.2 // indirect
+	github.com/labstack/gommon v0.4.2 // indirect
+	github.com/mattn/go-colorable v0.1.13 // indirect
+	github.com/mattn/go-isatty v0.0.20 // indirect
+	github.com/valyala/bytebufferpool v1.0.0 // indirect
+	github.com/valyala/fasttemplate v1.2.2 // indirect
+	golang.org/x/crypto v0.27.0 // indirect
+	golang.org/x/net v0.24.0 // indirect
+	golang.org/x/sync v0.8.0 // indirect
+	golang.org/x/sys v0.25.0 // indirect
+	golang.org/x/text v0.18.0 // indirect
+)
+
+replace problem => ../problem
+
+replace tlsconfig => ../tlsconfig
+
+replace tuning => ../tuning
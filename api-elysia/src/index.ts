@@ -26,6 +26,27 @@ interface User {
   created_at: Date;
 }
 
+// ---------------------------------------------------------------------------
+// Helpers
+// ---------------------------------------------------------------------------
+
+// matchRoute reports whether path fits a route pattern whose ":name"
+// segments match any single non-empty segment.
+function matchRoute(pattern: string, path: string): boolean {
+  const ps = pattern.split("/");
+  const segs = path.split("/");
+  if (ps.length !== segs.length) return false;
+  return ps.every((p, i) => p === segs[i] || (p.startsWith(":") && segs[i] !== ""));
+}
+
+// allowedMethods lists the methods registered for path. The explicit return
+// type keeps app's inferred type from depending on itself.
+function allowedMethods(path: string): string[] {
+  return app.routes
+    .filter((r) => matchRoute(r.path, path))
+    .map((r) => r.method);
+}
+
 // ---------------------------------------------------------------------------
 // App
 // ---------------------------------------------------------------------------
@@ -283,15 +304,23 @@ const app = new Elysia()
   // -------------------------------------------------------------------------
   // Global error handler
   // -------------------------------------------------------------------------
-  .onError(({ code, error, set }) => {
+  .onError(({ code, error, set, path }) => {
     if (code === "VALIDATION") {
       set.status = 400;
       return { error: "Validation error", details: error.message };
     }
 
+    // Elysia answers a known path hit with the wrong method as NOT_FOUND
+    // too; the registered routes tell the two apart, only on a miss.
     if (code === "NOT_FOUND") {
-      set.status = 404;
-      return { error: "Route not found" };
+      const allowed = allowedMethods(path);
+      if (allowed.length === 0) {
+        set.status = 404;
+        return { error: "Not found" };
+      }
+      set.headers["allow"] = allowed.join(", ");
+      set.status = 405;
+      return { error: "Method not allowed" };
     }
 
     console.error("[error]", error);
@@ -204,6 +204,29 @@ app.delete('/users/:id', async (req, res) => {
   }
 });
 
+// ---------------------------------------------------------------------------
+// Router misses — the JSON envelope shared by every implementation
+// ---------------------------------------------------------------------------
+
+// Express has no 405 of its own: a known path hit with the wrong method
+// falls through to here like an unknown one, so the routes are matched
+// again to tell the two apart. Only misses pay for it.
+app.use((req, res) => {
+  const allowed = [];
+  for (const layer of app._router.stack) {
+    if (layer.route && layer.match(req.path)) {
+      for (const method of Object.keys(layer.route.methods)) {
+        allowed.push(method.toUpperCase());
+      }
+    }
+  }
+  if (allowed.length === 0) {
+    return res.status(404).json({ error: 'Not found' });
+  }
+  res.set('Allow', allowed.join(', '));
+  res.status(405).json({ error: 'Method not allowed' });
+});
+
 // ---------------------------------------------------------------------------
 // Start server
 // ---------------------------------------------------------------------------
@@ -0,0 +1,16 @@
+package main
+
+import "github.com/valyala/fasthttp"
+
+// ---------------------------------------------------------------------------
+// TLS listener (TLS_CERT, TLS_KEY; config from the shared tlsconfig package)
+// ---------------------------------------------------------------------------
+
+// listenAndServe serves srv on addr, over TLS when it has a TLSConfig.
+// fasthttp speaks HTTP/1.1 only, so no other protocol is offered via ALPN.
+func listenAndServe(srv *fasthttp.Server, addr string) error {
+	if srv.TLSConfig != nil {
+		return srv.ListenAndServeTLS(addr, "", "")
+	}
+	return srv.ListenAndServe(addr)
+}
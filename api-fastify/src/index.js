@@ -42,6 +42,24 @@ const errorSchema = {
   },
 };
 
+// --- Route table for the not-found handler ---
+
+// Fastify answers a known path hit with the wrong method as a plain 404, so
+// the registered routes are recorded to tell the two apart on a miss.
+const routes = [];
+fastify.addHook('onRoute', ({ method, url }) => {
+  for (const m of [].concat(method)) routes.push({ method: m, url });
+});
+
+// matchRoute reports whether path fits a route pattern whose ":name"
+// segments match any single non-empty segment.
+function matchRoute(pattern, path) {
+  const ps = pattern.split('/');
+  const segs = path.split('/');
+  if (ps.length !== segs.length) return false;
+  return ps.every((p, i) => p === segs[i] || (p.startsWith(':') && segs[i] !== ''));
+}
+
 // --- Routes ---
 
 // GET /
@@ -309,6 +327,21 @@ fastify.delete('/users/:id', {
   reply.code(204).send();
 });
 
+// --- Not-found handler ---
+// Router misses get the JSON envelope shared by every implementation
+// instead of Fastify's {"message", "error", "statusCode"} body.
+fastify.setNotFoundHandler((req, reply) => {
+  const path = req.url.split('?')[0];
+  const allowed = routes.filter((r) => matchRoute(r.url, path)).map((r) => r.method);
+  if (allowed.length === 0) {
+    reply.code(404);
+    return { error: 'Not found' };
+  }
+  reply.header('Allow', allowed.join(', '));
+  reply.code(405);
+  return { error: 'Method not allowed' };
+});
+
 // --- Error handler ---
 fastify.setErrorHandler((err, req, reply) => {
   const statusCode = err.statusCode || 500;
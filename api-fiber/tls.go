@@ -0,0 +1,26 @@
+package main
+
+import (
+	"crypto/tls"
+	"net"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ---------------------------------------------------------------------------
+// TLS listener (TLS_CERT, TLS_KEY; config from the shared tlsconfig package)
+// ---------------------------------------------------------------------------
+
+// listenAndServe serves app on addr, over TLS when tlsConfig is set. Fiber's
+// ListenTLS only takes certificate files, so the listener is wrapped here to
+// keep SIGHUP reloads; fasthttp speaks HTTP/1.1 only.
+func listenAndServe(app *fiber.App, addr string, tlsConfig *tls.Config) error {
+	if tlsConfig == nil {
+		return app.Listen(addr)
+	}
+	ln, err := net.Listen("tcp4", addr)
+	if err != nil {
+		return err
+	}
+	return app.Listener(tls.NewListener(ln, tlsConfig))
+}
@@ -0,0 +1,42 @@
+package schema
+
+import (
+	"time"
+
+	"entgo.io/ent"
+	"entgo.io/ent/dialect/entsql"
+	"entgo.io/ent/schema"
+	"entgo.io/ent/schema/field"
+)
+
+// User mirrors the users table from scripts/init.sql. The version column is
+// left out because no endpoint of this variant reads or writes it.
+type User struct {
+	ent.Schema
+}
+
+// Annotations pins the table name to the one created by scripts/init.sql.
+func (User) Annotations() []schema.Annotation {
+	return []schema.Annotation{
+		entsql.Annotation{Table: "users"},
+	}
+}
+
+// Fields of the User.
+func (User) Fields() []ent.Field {
+	return []ent.Field{
+		field.String("name").
+			MaxLen(255),
+		field.String("email").
+			MaxLen(255).
+			Unique(),
+		field.Int("age").
+			Optional().
+			Nillable(),
+		// ent's INSERT only reads back the id, so the timestamp is set
+		// client-side instead of by the column default.
+		field.Time("created_at").
+			Default(time.Now).
+			Immutable(),
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"entgo.io/ent/dialect"
+	entsql "entgo.io/ent/dialect/sql"
+	_ "github.com/lib/pq"
+
+	"api-gin-ent/ent"
+	"api-gin-ent/ent/user"
+)
+
+// ---------------------------------------------------------------------------
+// ent repository
+// ---------------------------------------------------------------------------
+
+// setupRepository opens the pool with lib/pq, the driver every other Go
+// implementation uses, and wraps it in the ent client generated from
+// ent/schema.
+func setupRepository() (UserRepository, func()) {
+	sqlDB, err := sql.Open("postgres", databaseURL())
+	if err != nil {
+		log.Fatalf("failed to open database: %v", err)
+	}
+
+	sqlDB.SetMaxOpenConns(dbPoolSize)
+	sqlDB.SetMaxIdleConns(dbPoolSize)
+	sqlDB.SetConnMaxLifetime(0)
+	sqlDB.SetConnMaxIdleTime(30 * time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := sqlDB.PingContext(ctx); err != nil {
+		log.Fatalf("failed to connect to database: %v", err)
+	}
+
+	client := ent.NewClient(ent.Driver(entsql.OpenDB(dialect.Postgres, sqlDB)))
+
+	log.Println("database connection established")
+	return &entUserRepository{client: client}, func() { client.Close() }
+}
+
+type entUserRepository struct {
+	client *ent.Client
+}
+
+func toUser(e *ent.User) User {
+	return User{ID: e.ID, Name: e.Name, Email: e.Email, Age: e.Age, CreatedAt: e.CreatedAt}
+}
+
+func toUsers(es []*ent.User) []User {
+	users := make([]User, len(es))
+	for i, e := range es {
+		users[i] = toUser(e)
+	}
+	return users
+}
+
+// byRandom orders by RANDOM(); ent only generates per-field orderings.
+func byRandom(s *entsql.Selector) {
+	s.OrderExpr(entsql.Expr("RANDOM()"))
+}
+
+// entError translates ent and driver errors into the repository errors.
+// Constraint errors wrap the driver error, so isUniqueViolation sees the
+// SQLSTATE through them.
+func entError(err error) error {
+	switch {
+	case ent.IsNotFound(err):
+		return ErrNotFound
+	case isUniqueViolation(err):
+		return ErrEmailTaken
+	default:
+		return err
+	}
+}
+
+func (r *entUserRepository) Random(ctx context.Context) (User, error) {
+	e, err := r.client.User.Query().Order(byRandom).First(ctx)
+	if err != nil {
+		return User{}, entError(err)
+	}
+	return toUser(e), nil
+}
+
+func (r *entUserRepository) RandomN(ctx context.Context, n int) ([]User, error) {
+	es, err := r.client.User.Query().Order(byRandom).Limit(n).All(ctx)
+	if err != nil {
+		return nil, entError(err)
+	}
+	return toUsers(es), nil
+}
+
+func (r *entUserRepository) List(ctx context.Context, limit, offset int) ([]User, error) {
+	q := r.client.User.Query().Order(user.ByID())
+	if limit > 0 {
+		q = q.Limit(limit).Offset(offset)
+	}
+	es, err := q.All(ctx)
+	if err != nil {
+		return nil, entError(err)
+	}
+	return toUsers(es), nil
+}
+
+func (r *entUserRepository) Count(ctx context.Context) (int, error) {
+	total, err := r.client.User.Query().Count(ctx)
+	if err != nil {
+		return 0, entError(err)
+	}
+	return total, nil
+}
+
+func (r *entUserRepository) GetByID(ctx context.Context, id int) (User, error) {
+	e, err := r.client.User.Get(ctx, id)
+	if err != nil {
+		return User{}, entError(err)
+	}
+	return toUser(e), nil
+}
+
+func (r *entUserRepository) Create(ctx context.Context, req CreateUserRequest) (User, error) {
+	e, err := r.client.User.Create().
+		SetName(req.Name).
+		SetEmail(req.Email).
+		SetNillableAge(req.Age).
+		Save(ctx)
+	if err != nil {
+		return User{}, entError(err)
+	}
+	return toUser(e), nil
+}
+
+// Update sets only the provided fields. ent runs the UPDATE and the SELECT
+// that reads the row back in one transaction, where api-gin uses RETURNING.
+func (r *entUserRepository) Update(ctx context.Context, id int, req UpdateUserRequest) (User, error) {
+	e, err := r.client.User.UpdateOneID(id).
+		SetNillableName(req.Name).
+		SetNillableEmail(req.Email).
+		SetNillableAge(req.Age).
+		Save(ctx)
+	if err != nil {
+		return User{}, entError(err)
+	}
+	return toUser(e), nil
+}
+
+func (r *entUserRepository) Delete(ctx context.Context, id int) error {
+	if err := r.client.User.DeleteOneID(id).Exec(ctx); err != nil {
+		return entError(err)
+	}
+	return nil
+}
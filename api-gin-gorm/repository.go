@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	_ "github.com/lib/pq"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+)
+
+// ---------------------------------------------------------------------------
+// GORM repository
+// ---------------------------------------------------------------------------
+
+// userModel maps User onto the users table for GORM. created_at is left to
+// the column default and read back through RETURNING.
+type userModel struct {
+	ID        int       `gorm:"primaryKey"`
+	Name      string    `gorm:"not null"`
+	Email     string    `gorm:"not null;unique"`
+	Age       *int
+	CreatedAt time.Time `gorm:"<-:false"`
+}
+
+func (userModel) TableName() string { return "users" }
+
+func (m userModel) toUser() User {
+	return User{ID: m.ID, Name: m.Name, Email: m.Email, Age: m.Age, CreatedAt: m.CreatedAt}
+}
+
+func toUsers(models []userModel) []User {
+	users := make([]User, len(models))
+	for i, m := range models {
+		users[i] = m.toUser()
+	}
+	return users
+}
+
+// setupRepository opens the pool with lib/pq, the driver every other Go
+// implementation uses, and hands it to GORM, so the ORM is the only
+// difference from api-gin's data layer.
+func setupRepository() (UserRepository, func()) {
+	sqlDB, err := sql.Open("postgres", databaseURL())
+	if err != nil {
+		log.Fatalf("failed to open database: %v", err)
+	}
+
+	sqlDB.SetMaxOpenConns(dbPoolSize)
+	sqlDB.SetMaxIdleConns(dbPoolSize)
+	sqlDB.SetConnMaxLifetime(0)
+	sqlDB.SetConnMaxIdleTime(30 * time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := sqlDB.PingContext(ctx); err != nil {
+		log.Fatalf("failed to connect to database: %v", err)
+	}
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		// Single statements need no wrapping transaction; api-gin runs
+		// them bare too.
+		SkipDefaultTransaction: true,
+		// Select the mapped columns rather than *, matching the raw SQL.
+		QueryFields: true,
+		Logger:      logger.Discard,
+	})
+	if err != nil {
+		log.Fatalf("failed to initialise gorm: %v", err)
+	}
+
+	log.Println("database connection established")
+	return &gormUserRepository{db: db}, func() { sqlDB.Close() }
+}
+
+type gormUserRepository struct {
+	db *gorm.DB
+}
+
+// returningUser lists the columns read back by INSERT and UPDATE; an empty
+// clause.Returning would be RETURNING *.
+var returningUser = clause.Returning{Columns: []clause.Column{
+	{Name: "id"}, {Name: "name"}, {Name: "email"}, {Name: "age"}, {Name: "created_at"},
+}}
+
+// gormError translates GORM and driver errors into the repository errors.
+func gormError(err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return ErrNotFound
+	case isUniqueViolation(err):
+		return ErrEmailTaken
+	default:
+		return err
+	}
+}
+
+func (r *gormUserRepository) Random(ctx context.Context) (User, error) {
+	var m userModel
+	if err := r.db.WithContext(ctx).Order("RANDOM()").Take(&m).Error; err != nil {
+		return User{}, gormError(err)
+	}
+	return m.toUser(), nil
+}
+
+func (r *gormUserRepository) RandomN(ctx context.Context, n int) ([]User, error) {
+	models := make([]userModel, 0, n)
+	if err := r.db.WithContext(ctx).Order("RANDOM()").Limit(n).Find(&models).Error; err != nil {
+		return nil, gormError(err)
+	}
+	return toUsers(models), nil
+}
+
+func (r *gormUserRepository) List(ctx context.Context, limit, offset int) ([]User, error) {
+	q := r.db.WithContext(ctx).Order("id")
+	if limit > 0 {
+		q = q.Limit(limit).Offset(offset)
+	}
+	var models []userModel
+	if err := q.Find(&models).Error; err != nil {
+		return nil, gormError(err)
+	}
+	return toUsers(models), nil
+}
+
+func (r *gormUserRepository) Count(ctx context.Context) (int, error) {
+	var total int64
+	if err := r.db.WithContext(ctx).Model(&userModel{}).Count(&total).Error; err != nil {
+		return 0, gormError(err)
+	}
+	return int(total), nil
+}
+
+func (r *gormUserRepository) GetByID(ctx context.Context, id int) (User, error) {
+	var m userModel
+	if err := r.db.WithContext(ctx).Take(&m, id).Error; err != nil {
+		return User{}, gormError(err)
+	}
+	return m.toUser(), nil
+}
+
+func (r *gormUserRepository) Create(ctx context.Context, req CreateUserRequest) (User, error) {
+	m := userModel{Name: req.Name, Email: req.Email, Age: req.Age}
+	if err := r.db.WithContext(ctx).Clauses(returningUser).Create(&m).Error; err != nil {
+		return User{}, gormError(err)
+	}
+	return m.toUser(), nil
+}
+
+// Update writes only the provided fields, like the COALESCE update in the
+// raw SQL implementations.
+func (r *gormUserRepository) Update(ctx context.Context, id int, req UpdateUserRequest) (User, error) {
+	fields := make(map[string]any, 3)
+	if req.Name != nil {
+		fields["name"] = *req.Name
+	}
+	if req.Email != nil {
+		fields["email"] = *req.Email
+	}
+	if req.Age != nil {
+		fields["age"] = *req.Age
+	}
+
+	var m userModel
+	res := r.db.WithContext(ctx).Model(&m).Clauses(returningUser).Where("id = ?", id).Updates(fields)
+	if res.Error != nil {
+		return User{}, gormError(res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return User{}, ErrNotFound
+	}
+	return m.toUser(), nil
+}
+
+func (r *gormUserRepository) Delete(ctx context.Context, id int) error {
+	res := r.db.WithContext(ctx).Delete(&userModel{}, id)
+	if res.Error != nil {
+		return gormError(res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package main
+
+//go:generate sqlc generate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	_ "github.com/lib/pq"
+
+	"api-gin-sqlc/db"
+)
+
+// ---------------------------------------------------------------------------
+// sqlc repository
+// ---------------------------------------------------------------------------
+
+// setupRepository opens the pool with lib/pq, like every other Go
+// implementation, and wraps it in the sqlc-generated queries.
+func setupRepository() (UserRepository, func()) {
+	sqlDB, err := sql.Open("postgres", databaseURL())
+	if err != nil {
+		log.Fatalf("failed to open database: %v", err)
+	}
+
+	sqlDB.SetMaxOpenConns(dbPoolSize)
+	sqlDB.SetMaxIdleConns(dbPoolSize)
+	sqlDB.SetConnMaxLifetime(0)
+	sqlDB.SetConnMaxIdleTime(30 * time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := sqlDB.PingContext(ctx); err != nil {
+		log.Fatalf("failed to connect to database: %v", err)
+	}
+
+	log.Println("database connection established")
+	return &sqlcUserRepository{q: db.New(sqlDB)}, func() { sqlDB.Close() }
+}
+
+type sqlcUserRepository struct {
+	q *db.Queries
+}
+
+// toUser converts the generated row type into the API's User.
+func toUser(row db.User) User {
+	u := User{ID: int(row.ID), Name: row.Name, Email: row.Email, CreatedAt: row.CreatedAt}
+	if row.Age.Valid {
+		age := int(row.Age.Int32)
+		u.Age = &age
+	}
+	return u
+}
+
+func toUsers(rows []db.User) []User {
+	users := make([]User, len(rows))
+	for i, row := range rows {
+		users[i] = toUser(row)
+	}
+	return users
+}
+
+// nullInt32 converts an optional request field into its parameter type.
+func nullInt32(v *int) sql.NullInt32 {
+	if v == nil {
+		return sql.NullInt32{}
+	}
+	return sql.NullInt32{Int32: int32(*v), Valid: true}
+}
+
+func nullString(v *string) sql.NullString {
+	if v == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: *v, Valid: true}
+}
+
+// sqlcError translates driver errors into the repository errors.
+func sqlcError(err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return ErrNotFound
+	case isUniqueViolation(err):
+		return ErrEmailTaken
+	default:
+		return err
+	}
+}
+
+func (r *sqlcUserRepository) Random(ctx context.Context) (User, error) {
+	row, err := r.q.RandomUser(ctx)
+	if err != nil {
+		return User{}, sqlcError(err)
+	}
+	return toUser(row), nil
+}
+
+func (r *sqlcUserRepository) RandomN(ctx context.Context, n int) ([]User, error) {
+	rows, err := r.q.RandomUsers(ctx, int32(n))
+	if err != nil {
+		return nil, sqlcError(err)
+	}
+	return toUsers(rows), nil
+}
+
+func (r *sqlcUserRepository) List(ctx context.Context, limit, offset int) ([]User, error) {
+	var (
+		rows []db.User
+		err  error
+	)
+	if limit > 0 {
+		rows, err = r.q.PageUsers(ctx, db.PageUsersParams{Limit: int32(limit), Offset: int32(offset)})
+	} else {
+		rows, err = r.q.ListUsers(ctx)
+	}
+	if err != nil {
+		return nil, sqlcError(err)
+	}
+	return toUsers(rows), nil
+}
+
+func (r *sqlcUserRepository) Count(ctx context.Context) (int, error) {
+	total, err := r.q.CountUsers(ctx)
+	if err != nil {
+		return 0, sqlcError(err)
+	}
+	return int(total), nil
+}
+
+func (r *sqlcUserRepository) GetByID(ctx context.Context, id int) (User, error) {
+	row, err := r.q.GetUser(ctx, int32(id))
+	if err != nil {
+		return User{}, sqlcError(err)
+	}
+	return toUser(row), nil
+}
+
+func (r *sqlcUserRepository) Create(ctx context.Context, req CreateUserRequest) (User, error) {
+	row, err := r.q.CreateUser(ctx, db.CreateUserParams{
+		Name:  req.Name,
+		Email: req.Email,
+		Age:   nullInt32(req.Age),
+	})
+	if err != nil {
+		return User{}, sqlcError(err)
+	}
+	return toUser(row), nil
+}
+
+func (r *sqlcUserRepository) Update(ctx context.Context, id int, req UpdateUserRequest) (User, error) {
+	row, err := r.q.UpdateUser(ctx, db.UpdateUserParams{
+		Name:  nullString(req.Name),
+		Email: nullString(req.Email),
+		Age:   nullInt32(req.Age),
+		ID:    int32(id),
+	})
+	if err != nil {
+		return User{}, sqlcError(err)
+	}
+	return toUser(row), nil
+}
+
+func (r *sqlcUserRepository) Delete(ctx context.Context, id int) error {
+	n, err := r.q.DeleteUser(ctx, int32(id))
+	if err != nil {
+		return sqlcError(err)
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
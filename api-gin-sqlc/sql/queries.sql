@@ -0,0 +1,37 @@
+-- Same statements as the hand-written implementations; sqlc generates the
+-- typed Go functions in db/.
+
+-- name: RandomUser :one
+SELECT id, name, email, age, created_at FROM users ORDER BY RANDOM() LIMIT 1;
+
+-- name: RandomUsers :many
+SELECT id, name, email, age, created_at FROM users ORDER BY RANDOM() LIMIT $1;
+
+-- name: ListUsers :many
+SELECT id, name, email, age, created_at FROM users ORDER BY id;
+
+-- name: PageUsers :many
+SELECT id, name, email, age, created_at FROM users ORDER BY id LIMIT $1 OFFSET $2;
+
+-- name: CountUsers :one
+SELECT COUNT(*)::int FROM users;
+
+-- name: GetUser :one
+SELECT id, name, email, age, created_at FROM users WHERE id = $1;
+
+-- name: CreateUser :one
+INSERT INTO users (name, email, age)
+VALUES ($1, $2, $3)
+RETURNING id, name, email, age, created_at;
+
+-- name: UpdateUser :one
+-- COALESCE updates only the provided fields, the same SQL as api-gin.
+UPDATE users
+SET name  = COALESCE(sqlc.narg('name'), name),
+    email = COALESCE(sqlc.narg('email'), email),
+    age   = COALESCE(sqlc.narg('age'), age)
+WHERE id = sqlc.arg('id')
+RETURNING id, name, email, age, created_at;
+
+-- name: DeleteUser :execrows
+DELETE FROM users WHERE id = $1;
@@ -0,0 +1,10 @@
+-- The users table from scripts/init.sql, minus the version column these
+-- queries never read, so every query maps onto the generated db.User. sqlc
+-- only reads this file to type the queries; it is never applied from here.
+CREATE TABLE users (
+    id         SERIAL PRIMARY KEY,
+    name       VARCHAR(255) NOT NULL,
+    email      VARCHAR(255) NOT NULL UNIQUE,
+    age        INTEGER,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+)
+
+// ---------------------------------------------------------------------------
+// sqlx repository
+// ---------------------------------------------------------------------------
+
+// Same SQL as api-gin; sqlx maps the columns onto User through its db tags.
+const (
+	queryRandomUser  = `SELECT id, name, email, age, created_at FROM users ORDER BY RANDOM() LIMIT 1`
+	queryRandomUsers = `SELECT id, name, email, age, created_at FROM users ORDER BY RANDOM() LIMIT $1`
+	queryListUsers   = `SELECT id, name, email, age, created_at FROM users ORDER BY id`
+	queryPageUsers   = `SELECT id, name, email, age, created_at FROM users ORDER BY id LIMIT $1 OFFSET $2`
+	queryCountUsers  = `SELECT COUNT(*)::int FROM users`
+	queryGetUser     = `SELECT id, name, email, age, created_at FROM users WHERE id = $1`
+	// Named parameters are bound from the request structs' db tags.
+	queryCreateUser = `
+		INSERT INTO users (name, email, age)
+		VALUES (:name, :email, :age)
+		RETURNING id, name, email, age, created_at`
+	// COALESCE updates only the provided fields, the same SQL as api-gin.
+	queryUpdateUser = `
+		UPDATE users
+		SET name  = COALESCE(:name, name),
+		    email = COALESCE(:email, email),
+		    age   = COALESCE(:age, age)
+		WHERE id = :id
+		RETURNING id, name, email, age, created_at`
+	queryDeleteUser = `DELETE FROM users WHERE id = $1`
+)
+
+// setupRepository opens the pool through sqlx with lib/pq, the driver every
+// other Go implementation uses.
+func setupRepository() (UserRepository, func()) {
+	db, err := sqlx.Open("postgres", databaseURL())
+	if err != nil {
+		log.Fatalf("failed to open database: %v", err)
+	}
+
+	db.SetMaxOpenConns(dbPoolSize)
+	db.SetMaxIdleConns(dbPoolSize)
+	db.SetConnMaxLifetime(0)
+	db.SetConnMaxIdleTime(30 * time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := db.PingContext(ctx); err != nil {
+		log.Fatalf("failed to connect to database: %v", err)
+	}
+
+	log.Println("database connection established")
+	return &sqlxUserRepository{db: db}, func() { db.Close() }
+}
+
+type sqlxUserRepository struct {
+	db *sqlx.DB
+}
+
+// updateParams carries the named parameters of queryUpdateUser.
+type updateParams struct {
+	UpdateUserRequest
+	ID int `db:"id"`
+}
+
+// sqlxError translates driver errors into the repository errors.
+func sqlxError(err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return ErrNotFound
+	case isUniqueViolation(err):
+		return ErrEmailTaken
+	default:
+		return err
+	}
+}
+
+// namedGet runs a named query expected to return one row and scans it into
+// dest; sqlx has NamedQuery but no named counterpart of GetContext.
+func (r *sqlxUserRepository) namedGet(ctx context.Context, dest any, query string, arg any) error {
+	rows, err := r.db.NamedQueryContext(ctx, query, arg)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return sql.ErrNoRows
+	}
+	return rows.StructScan(dest)
+}
+
+func (r *sqlxUserRepository) Random(ctx context.Context) (User, error) {
+	var u User
+	if err := r.db.GetContext(ctx, &u, queryRandomUser); err != nil {
+		return User{}, sqlxError(err)
+	}
+	return u, nil
+}
+
+func (r *sqlxUserRepository) RandomN(ctx context.Context, n int) ([]User, error) {
+	users := make([]User, 0, n)
+	if err := r.db.SelectContext(ctx, &users, queryRandomUsers, n); err != nil {
+		return nil, sqlxError(err)
+	}
+	return users, nil
+}
+
+func (r *sqlxUserRepository) List(ctx context.Context, limit, offset int) ([]User, error) {
+	var users []User
+	var err error
+	if limit > 0 {
+		users = make([]User, 0, limit)
+		err = r.db.SelectContext(ctx, &users, queryPageUsers, limit, offset)
+	} else {
+		users = []User{}
+		err = r.db.SelectContext(ctx, &users, queryListUsers)
+	}
+	if err != nil {
+		return nil, sqlxError(err)
+	}
+	return users, nil
+}
+
+func (r *sqlxUserRepository) Count(ctx context.Context) (int, error) {
+	var total int
+	if err := r.db.GetContext(ctx, &total, queryCountUsers); err != nil {
+		return 0, sqlxError(err)
+	}
+	return total, nil
+}
+
+func (r *sqlxUserRepository) GetByID(ctx context.Context, id int) (User, error) {
+	var u User
+	if err := r.db.GetContext(ctx, &u, queryGetUser, id); err != nil {
+		return User{}, sqlxError(err)
+	}
+	return u, nil
+}
+
+func (r *sqlxUserRepository) Create(ctx context.Context, req CreateUserRequest) (User, error) {
+	var u User
+	if err := r.namedGet(ctx, &u, queryCreateUser, req); err != nil {
+		return User{}, sqlxError(err)
+	}
+	return u, nil
+}
+
+func (r *sqlxUserRepository) Update(ctx context.Context, id int, req UpdateUserRequest) (User, error) {
+	var u User
+	if err := r.namedGet(ctx, &u, queryUpdateUser, updateParams{req, id}); err != nil {
+		return User{}, sqlxError(err)
+	}
+	return u, nil
+}
+
+func (r *sqlxUserRepository) Delete(ctx context.Context, id int) error {
+	res, err := r.db.ExecContext(ctx, queryDeleteUser, id)
+	if err != nil {
+		return sqlxError(err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sony/gobreaker"
+)
+
+// ---------------------------------------------------------------------------
+// Database circuit breaker (DB_BREAKER)
+// ---------------------------------------------------------------------------
+
+// ErrCircuitOpen means the call was refused without touching the database
+// because the circuit breaker is open, or half-open with its probes already
+// in flight.
+var ErrCircuitOpen = errors.New("database circuit open")
+
+// newDBBreaker trips after failures consecutive database failures, fails
+// every call fast for timeout, then lets probes calls through half-open:
+// one failure reopens it, probes successes close it again.
+func newDBBreaker(failures, probes int, timeout time.Duration) *gobreaker.TwoStepCircuitBreaker {
+	return gobreaker.NewTwoStepCircuitBreaker(gobreaker.Settings{
+		Name:        "db",
+		MaxRequests: uint32(probes),
+		Timeout:     timeout,
+		ReadyToTrip: func(counts gobreaker.Counts) bool {
+			return counts.ConsecutiveFailures >= uint32(failures)
+		},
+		OnStateChange: func(name string, from, to gobreaker.State) {
+			log.Printf("%s circuit breaker: %s -> %s", name, from, to)
+		},
+	})
+}
+
+// breakerUserRepository routes every repository call through a circuit
+// breaker, so once the database is down or degraded requests get 503 at
+// once instead of each one waiting out the pool and DB_QUERY_TIMEOUT. It
+// wraps the query timeout, which makes a timed-out query count as a
+// failure.
+type breakerUserRepository struct {
+	repo    UserRepository
+	breaker *gobreaker.TwoStepCircuitBreaker
+}
+
+func withBreaker(repo UserRepository, breaker *gobreaker.TwoStepCircuitBreaker) UserRepository {
+	return &breakerUserRepository{repo: repo, breaker: breaker}
+}
+
+// dbHealthy reports whether err leaves the database's health unquestioned:
+// no error, a client that went away, or a domain outcome such as a missing
+// row or a constraint violation. Everything else counts as a failure,
+// ErrPoolExhausted included: with a hung database the pool saturates and
+// every call ends that way.
+func dbHealthy(err error) bool {
+	return err == nil ||
+		errors.Is(err, context.Canceled) ||
+		errors.Is(err, ErrNotFound) ||
+		errors.Is(err, ErrEmailTaken) ||
+		errors.Is(err, ErrVersionMismatch) ||
+		errors.Is(err, ErrNullValue) ||
+		errors.Is(err, ErrInvalidReference)
+}
+
+// allow asks the breaker to admit a call; done records its outcome.
+func (r *breakerUserRepository) allow() (done func(success bool), err error) {
+	done, err = r.breaker.Allow()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrCircuitOpen, err)
+	}
+	return done, nil
+}
+
+// guarded runs fn if the breaker admits the call and records its outcome.
+func guarded[T any](r *breakerUserRepository, fn func() (T, error)) (T, error) {
+	done, err := r.allow()
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	v, err := fn()
+	done(dbHealthy(err))
+	return v, err
+}
+
+func (r *breakerUserRepository) Random(ctx context.Context, mode RandomMode) (User, error) {
+	return guarded(r, func() (User, error) { return r.repo.Random(ctx, mode) })
+}
+
+func (r *breakerUserRepository) RandomSampled(ctx context.Context) (User, error) {
+	return guarded(r, func() (User, error) { return r.repo.RandomSampled(ctx) })
+}
+
+func (r *breakerUserRepository) RandomN(ctx context.Context, n int, mode RandomMode) ([]User, error) {
+	return guarded(r, func() ([]User, error) { return r.repo.RandomN(ctx, n, mode) })
+}
+
+func (r *breakerUserRepository) RandomPinned(ctx context.Context, n int, mode RandomMode) ([]User, error) {
+	return guarded(r, func() ([]User, error) { return r.repo.RandomPinned(ctx, n, mode) })
+}
+
+func (r *breakerUserRepository) RandomBatched(ctx context.Context, n int, mode RandomMode) ([]User, error) {
+	return guarded(r, func() ([]User, error) { return r.repo.RandomBatched(ctx, n, mode) })
+}
+
+func (r *breakerUserRepository) List(ctx context.Context, order UserOrder, limit, offset int) ([]User, error) {
+	return guarded(r, func() ([]User, error) { return r.repo.List(ctx, order, limit, offset) })
+}
+
+// Stream does not hold an error returned by fn against the database: that
+// is the response write failing, not the query.
+func (r *breakerUserRepository) Stream(ctx context.Context, order UserOrder, limit, offset int, fn func(User) error) error {
+	done, err := r.allow()
+	if err != nil {
+		return err
+	}
+	var fnErr error
+	err = r.repo.Stream(ctx, order, limit, offset, func(u User) error {
+		fnErr = fn(u)
+		return fnErr
+	})
+	done(fnErr != nil || dbHealthy(err))
+	return err
+}
+
+func (r *breakerUserRepository) Count(ctx context.Context) (int, error) {
+	return guarded(r, func() (int, error) { return r.repo.Count(ctx) })
+}
+
+func (r *breakerUserRepository) Stats(ctx context.Context) (UserStats, error) {
+	return guarded(r, func() (UserStats, error) { return r.repo.Stats(ctx) })
+}
+
+func (r *breakerUserRepository) GetByID(ctx context.Context, id int) (User, error) {
+	return guarded(r, func() (User, error) { return r.repo.GetByID(ctx, id) })
+}
+
+func (r *breakerUserRepository) Create(ctx context.Context, req CreateUserRequest) (User, error) {
+	return guarded(r, func() (User, error) { return r.repo.Create(ctx, req) })
+}
+
+func (r *breakerUserRepository) CreateMinimal(ctx context.Context, req CreateUserRequest) (int, error) {
+	return guarded(r, func() (int, error) { return r.repo.CreateMinimal(ctx, req) })
+}
+
+func (r *breakerUserRepository) Update(ctx context.Context, id int, req UpdateUserRequest, version int) (User, error) {
+	return guarded(r, func() (User, error) { return r.repo.Update(ctx, id, req, version) })
+}
+
+func (r *breakerUserRepository) UpdateMany(ctx context.Context, reqs []BulkUpdateUserRequest) ([]User, error) {
+	return guarded(r, func() ([]User, error) { return r.repo.UpdateMany(ctx, reqs) })
+}
+
+func (r *breakerUserRepository) Replace(ctx context.Context, u User) (User, error) {
+	return guarded(r, func() (User, error) { return r.repo.Replace(ctx, u) })
+}
+
+func (r *breakerUserRepository) Delete(ctx context.Context, id int) error {
+	_, err := guarded(r, func() (struct{}, error) { return struct{}{}, r.repo.Delete(ctx, id) })
+	return err
+}
+
+// GET /healthz — 503 while the database circuit is open, so an orchestrator
+// or the load generator sees the degraded state without sending traffic
+// into it; 200 otherwise, with the state in detail once half-open. Always
+// 200 when DB_BREAKER is off (breaker nil).
+func handleHealthz(breaker *gobreaker.TwoStepCircuitBreaker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if breaker == nil {
+			c.JSON(http.StatusOK, StatusResponse{Status: "ok"})
+			return
+		}
+		switch breaker.State() {
+		case gobreaker.StateOpen:
+			setRetryAfter(c, retryAfterDelay)
+			c.JSON(http.StatusServiceUnavailable, StatusResponse{Status: "unavailable", Detail: ErrCircuitOpen.Error()})
+		case gobreaker.StateHalfOpen:
+			c.JSON(http.StatusOK, StatusResponse{Status: "ok", Detail: "database circuit half-open"})
+		default:
+			c.JSON(http.StatusOK, StatusResponse{Status: "ok"})
+		}
+	}
+}
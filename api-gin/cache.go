@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ---------------------------------------------------------------------------
+// In-process user cache (GET /cached-queries)
+// ---------------------------------------------------------------------------
+
+// userCacheShards spreads the cache over independently locked maps, so
+// concurrent readers and miss fills do not serialize on a single mutex.
+const userCacheShards = 64
+
+// userCache is a read-through cache of users by id, warmed with the whole
+// users table at startup. Entries expire after ttl (0 keeps them forever);
+// an expired or missing id is fetched from the repository and stored again.
+// Writes through the API do not invalidate it: like the TechEmpower cached
+// queries test, it models reference data.
+type userCache struct {
+	repo   UserRepository
+	ttl    time.Duration
+	maxID  int
+	shards [userCacheShards]userCacheShard
+}
+
+type userCacheShard struct {
+	mu      sync.RWMutex
+	entries map[int]userCacheEntry
+}
+
+type userCacheEntry struct {
+	user    User
+	expires time.Time // zero: never
+}
+
+func newUserCache(repo UserRepository, ttl time.Duration) *userCache {
+	c := &userCache{repo: repo, ttl: ttl}
+	for i := range c.shards {
+		c.shards[i].entries = make(map[int]userCacheEntry)
+	}
+	return c
+}
+
+// warm loads every user and fixes the id range lookups are drawn from.
+func (c *userCache) warm(ctx context.Context) (int, error) {
+	users, err := c.repo.List(ctx, UserOrder{}, 0, 0)
+	if err != nil {
+		return 0, err
+	}
+	for _, u := range users {
+		c.store(u)
+		c.maxID = max(c.maxID, u.ID)
+	}
+	return len(users), nil
+}
+
+func (c *userCache) shard(id int) *userCacheShard {
+	return &c.shards[uint(id)%userCacheShards]
+}
+
+func (c *userCache) store(u User) {
+	e := userCacheEntry{user: u}
+	if c.ttl > 0 {
+		e.expires = time.Now().Add(c.ttl)
+	}
+	s := c.shard(u.ID)
+	s.mu.Lock()
+	s.entries[u.ID] = e
+	s.mu.Unlock()
+}
+
+// get returns the user with id from the cache, or from the repository on a
+// miss or an expired entry.
+func (c *userCache) get(ctx context.Context, id int) (User, error) {
+	s := c.shard(id)
+	s.mu.RLock()
+	e, ok := s.entries[id]
+	s.mu.RUnlock()
+	if ok && (e.expires.IsZero() || time.Now().Before(e.expires)) {
+		return e.user, nil
+	}
+
+	user, err := c.repo.GetByID(ctx, id)
+	if errors.Is(err, ErrNotFound) && ok {
+		s.mu.Lock()
+		delete(s.entries, id)
+		s.mu.Unlock()
+	}
+	if err != nil {
+		return User{}, err
+	}
+	c.store(user)
+	return user, nil
+}
+
+// maxCacheMissRetries bounds how many ids are redrawn when one has been
+// deleted since the cache was warmed.
+const maxCacheMissRetries = 3
+
+// random returns a user with an id drawn uniformly from the warmed range.
+func (c *userCache) random(ctx context.Context) (User, error) {
+	if c.maxID == 0 {
+		return User{}, ErrNotFound
+	}
+	var err error
+	for i := 0; i <= maxCacheMissRetries; i++ {
+		var user User
+		user, err = c.get(ctx, rand.IntN(c.maxID)+1)
+		if !errors.Is(err, ErrNotFound) {
+			return user, err
+		}
+	}
+	return User{}, err
+}
+
+// GET /cached-queries?count=N — N random users from the in-process cache
+// (1-maxCount, default 1), parsed like /queries. Ids may repeat.
+func handleCachedQueries(cache *userCache, maxCount int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		count := parseCount(c.Query("count"), maxCount)
+
+		users := getUsers(count)
+		for i := 0; i < count; i++ {
+			user, err := cache.random(c.Request.Context())
+			if err != nil {
+				respondError(c, err, "No users found")
+				return
+			}
+			users = append(users, user)
+		}
+
+		renderJSON(c, http.StatusOK, Users(users))
+		putUsers(users)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// ---------------------------------------------------------------------------
+// /db request coalescing (DB_COALESCE)
+// ---------------------------------------------------------------------------
+
+// coalescingUserRepository collapses concurrent /db lookups into one query:
+// while a Random (or RandomSampled) call is in flight, every other caller
+// with the same mode waits for it and receives the same user instead of
+// issuing its own query. With ttl > 0 the result is then also served to
+// later callers until it expires. Either way /db stops returning an
+// independent random row per request, which is the point: the difference in
+// the results shows what coalescing buys under load.
+//
+// The shared query runs detached from the cancellation of the caller that
+// started it, so one client disconnecting does not fail the others; it is
+// still bounded by DB_QUERY_TIMEOUT. A waiter whose own context ends stops
+// waiting. Every other method goes straight to the wrapped repository.
+type coalescingUserRepository struct {
+	UserRepository
+	ttl   time.Duration
+	group singleflight.Group
+
+	mu     sync.Mutex
+	cached map[string]cachedUser
+}
+
+type cachedUser struct {
+	user    User
+	expires time.Time
+}
+
+func withCoalescing(repo UserRepository, ttl time.Duration) UserRepository {
+	return &coalescingUserRepository{
+		UserRepository: repo,
+		ttl:            ttl,
+		cached:         make(map[string]cachedUser),
+	}
+}
+
+func (r *coalescingUserRepository) Random(ctx context.Context, mode RandomMode) (User, error) {
+	key := "by_id"
+	if mode == RandomScan {
+		key = "scan"
+	}
+	return r.do(ctx, key, func(ctx context.Context) (User, error) {
+		return r.UserRepository.Random(ctx, mode)
+	})
+}
+
+func (r *coalescingUserRepository) RandomSampled(ctx context.Context) (User, error) {
+	return r.do(ctx, "sample", r.UserRepository.RandomSampled)
+}
+
+func (r *coalescingUserRepository) do(ctx context.Context, key string, fn func(context.Context) (User, error)) (User, error) {
+	if r.ttl > 0 {
+		r.mu.Lock()
+		c, ok := r.cached[key]
+		r.mu.Unlock()
+		if ok && time.Now().Before(c.expires) {
+			return c.user, nil
+		}
+	}
+
+	ch := r.group.DoChan(key, func() (any, error) {
+		user, err := fn(context.WithoutCancel(ctx))
+		if err == nil && r.ttl > 0 {
+			r.mu.Lock()
+			r.cached[key] = cachedUser{user: user, expires: time.Now().Add(r.ttl)}
+			r.mu.Unlock()
+		}
+		return user, err
+	})
+
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return User{}, res.Err
+		}
+		return res.Val.(User), nil
+	case <-ctx.Done():
+		return User{}, ctx.Err()
+	}
+}
@@ -0,0 +1,416 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"tuning"
+)
+
+// ---------------------------------------------------------------------------
+// Feature flags
+// ---------------------------------------------------------------------------
+
+// Features is the resolved set of env-gated behaviour, read once at startup.
+// setupRouter and main decide what to install from it, and GET /features
+// reports it so every benchmark run records the configuration it measured.
+// Connection strings and key material paths are deliberately left out; only
+// their presence shows.
+type Features struct {
+	AccessLog         bool `json:"access_log"`
+	Metrics           bool `json:"metrics"`
+	Tracing           bool `json:"tracing"`
+	StrictJSON        bool `json:"strict_json"`
+	StrictQueryParams bool `json:"strict_query_params"`
+	DebugRoutes       bool `json:"debug_routes"`
+	PanicDetail       bool `json:"panic_detail"`
+
+	TrailingSlashRedirect bool `json:"trailing_slash_redirect"`
+
+	DataSource         string `json:"data_source"`
+	MemorySeedSize     int    `json:"memory_seed_size,omitempty"`
+	PreparedStatements bool   `json:"prepared_statement_cache"`
+
+	DBMaxOpenConns    int          `json:"db_max_open_conns"`
+	DBMaxIdleConns    int          `json:"db_max_idle_conns"`
+	DBConnMaxLifetime flagDuration `json:"db_conn_max_lifetime"`
+	DBConnMaxIdleTime flagDuration `json:"db_conn_max_idle_time"`
+	QueryTimeout      flagDuration `json:"query_timeout"`
+	StatementTimeout  int          `json:"db_statement_timeout_ms"`
+	ConnectionCheck   int          `json:"db_connection_check_ms"`
+
+	DBBreaker         bool         `json:"db_breaker"`
+	DBBreakerFailures int          `json:"db_breaker_failures"`
+	DBBreakerProbes   int          `json:"db_breaker_probes"`
+	DBBreakerTimeout  flagDuration `json:"db_breaker_timeout"`
+
+	DBRetry        bool         `json:"db_retry"`
+	DBRetryMax     int          `json:"db_retry_max"`
+	DBRetryBackoff flagDuration `json:"db_retry_backoff"`
+
+	Cache    bool         `json:"cache"`
+	CacheURL string       `json:"-"`
+	CacheTTL flagDuration `json:"cache_ttl"`
+
+	DBCoalesce    bool         `json:"db_coalesce"`
+	DBCoalesceTTL flagDuration `json:"db_coalesce_ttl"`
+
+	CachedQueries    bool         `json:"cached_queries"`
+	CachedQueriesTTL flagDuration `json:"cached_queries_ttl"`
+
+	Backpressure       bool  `json:"backpressure"`
+	BackpressureFactor int   `json:"backpressure_factor"`
+	MaxConcurrency     int   `json:"max_concurrency"`
+	MaxQueriesCount    int   `json:"max_queries_count"`
+	QueryWorkers       int   `json:"query_workers"`
+	MaxBodyBytes       int64 `json:"max_body_bytes"`
+
+	FaultRate      float64 `json:"fault_rate"`
+	FaultErrorRate float64 `json:"fault_error_rate"`
+	FaultDelayMS   int     `json:"fault_delay_ms"`
+	FaultSeed      int64   `json:"fault_seed"`
+
+	ReadReplica          bool    `json:"read_replica"`
+	ReplicaDSN           string  `json:"-"`
+	MaxReplicaLagSeconds float64 `json:"max_replica_lag_seconds"`
+
+	RetryAfterFormat string   `json:"retry_after_format"`
+	DisabledRoutes   []string `json:"disabled_routes"`
+
+	HTTPReadTimeout       flagDuration `json:"http_read_timeout"`
+	HTTPReadHeaderTimeout flagDuration `json:"http_read_header_timeout"`
+	HTTPWriteTimeout      flagDuration `json:"http_write_timeout"`
+	HTTPIdleTimeout       flagDuration `json:"http_idle_timeout"`
+	HTTPMaxHeaderBytes    int          `json:"http_max_header_bytes"`
+	HTTPKeepAlives        bool         `json:"http_keep_alives"`
+
+	TrustedProxies  []string `json:"trusted_proxies"`
+	RemoteIPHeaders []string `json:"remote_ip_headers"`
+
+	Port               string `json:"port"`
+	Listen             string `json:"listen,omitempty"`
+	ReusePortListeners int    `json:"reuseport_listeners"`
+	Prefork            bool   `json:"prefork"`
+
+	AdminPort string `json:"admin_port,omitempty"`
+	GRPCPort  string `json:"grpc_port,omitempty"`
+	HTTP3Port string `json:"http3_port,omitempty"`
+	HTTP3Cert string `json:"-"`
+	HTTP3Key  string `json:"-"`
+	TLS       bool   `json:"tls"`
+	TLSCert   string `json:"-"`
+	TLSKey    string `json:"-"`
+
+	GOMAXPROCS int     `json:"gomaxprocs"`
+	CPUQuota   float64 `json:"cpu_quota"`
+	GOGC       int     `json:"gogc"`
+	GOMemLimit int64   `json:"gomemlimit"`
+	Ballast    int64   `json:"ballast"`
+
+	JSONEngine string `json:"json_engine"`
+	EasyJSON   bool   `json:"easyjson"`
+
+	PremarshalJSON bool `json:"premarshal_json"`
+
+	PGOBuild       string `json:"pgo_build"`
+	PGOProfile     bool   `json:"pgo_profile"`
+	PGOProfilePath string `json:"pgo_profile_path,omitempty"`
+
+	DBStartupTimeout  flagDuration `json:"db_startup_timeout"`
+	AutoMigrate       bool         `json:"auto_migrate"`
+	PoolPrewarm       bool         `json:"pool_prewarm"`
+	PoolRampDuration  flagDuration `json:"pool_ramp_duration"`
+	PoolStatsInterval flagDuration `json:"pool_stats_interval"`
+	PreShutdownDelay  flagDuration `json:"pre_shutdown_delay"`
+	DrainTimeout      flagDuration `json:"drain_timeout"`
+}
+
+// flagDuration renders as "1.5s" in JSON instead of integer nanoseconds.
+type flagDuration time.Duration
+
+func (d flagDuration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// devEnv reports whether APP_ENV names a development environment. Anything
+// else, unset included, is treated as production.
+func devEnv(env string) bool {
+	switch env {
+	case "development", "dev", "local", "test":
+		return true
+	}
+	return false
+}
+
+// loadFeatures reads every feature flag from the environment and validates
+// the numeric ones.
+func loadFeatures() (Features, error) {
+	rt := tuning.Current()
+	f := Features{
+		AccessLog:         envBool("ACCESS_LOG"),
+		Metrics:           envBool("METRICS_ENABLED"),
+		Tracing:           envBool("TRACING_ENABLED"),
+		StrictJSON:        envBool("STRICT_JSON"),
+		StrictQueryParams: envBool("STRICT_QUERY_PARAMS"),
+		DebugRoutes:       os.Getenv("APP_ENV") == "test",
+		PanicDetail:       devEnv(os.Getenv("APP_ENV")),
+
+		TrailingSlashRedirect: envBool("TRAILING_SLASH_REDIRECT"),
+
+		DataSource:         os.Getenv("DATA_SOURCE"),
+		PreparedStatements: envBool("DB_PREPARED_STATEMENT_CACHE"),
+
+		DBMaxOpenConns:    envInt("DB_MAX_OPEN_CONNS", dbPoolSize),
+		DBMaxIdleConns:    envInt("DB_MAX_IDLE_CONNS", dbPoolSize),
+		DBConnMaxLifetime: flagDuration(envDurationLimit("DB_CONN_MAX_LIFETIME", 0)),
+		DBConnMaxIdleTime: flagDuration(envDurationLimit("DB_CONN_MAX_IDLE_TIME", 30*time.Second)),
+		QueryTimeout:      flagDuration(envDurationLimit("DB_QUERY_TIMEOUT", 2*time.Second)),
+		StatementTimeout:  envInt("DB_STATEMENT_TIMEOUT_MS", 0),
+		ConnectionCheck:   envInt("DB_CONNECTION_CHECK_MS", 0),
+
+		DBBreaker:         envBool("DB_BREAKER"),
+		DBBreakerFailures: envInt("DB_BREAKER_FAILURES", 5),
+		DBBreakerProbes:   envInt("DB_BREAKER_PROBES", 1),
+		DBBreakerTimeout:  flagDuration(envDuration("DB_BREAKER_TIMEOUT", 5*time.Second)),
+
+		DBRetry:        envBool("DB_RETRY"),
+		DBRetryMax:     envInt("DB_RETRY_MAX", 3),
+		DBRetryBackoff: flagDuration(envDuration("DB_RETRY_BACKOFF", 10*time.Millisecond)),
+
+		CacheURL: os.Getenv("CACHE_URL"),
+		CacheTTL: flagDuration(envDuration("CACHE_TTL", time.Minute)),
+
+		DBCoalesce:    envBool("DB_COALESCE"),
+		DBCoalesceTTL: flagDuration(envDurationLimit("DB_COALESCE_TTL", 0)),
+
+		CachedQueries:    envBool("CACHED_QUERIES"),
+		CachedQueriesTTL: flagDuration(envDurationLimit("CACHED_QUERIES_TTL", 0)),
+
+		Backpressure:       envBool("ENABLE_BACKPRESSURE"),
+		BackpressureFactor: envInt("BACKPRESSURE_FACTOR", 2),
+		MaxConcurrency:     envInt("MAX_CONCURRENCY", 0),
+		MaxQueriesCount:    envInt("MAX_QUERIES_COUNT", defaultMaxQueriesCount),
+		QueryWorkers:       envInt("QUERY_WORKERS", 0),
+		MaxBodyBytes:       int64(envInt("MAX_BODY_BYTES", defaultMaxBodyBytes)),
+
+		FaultRate:      envFloat("FAULT_RATE", 0),
+		FaultErrorRate: envFloat("FAULT_ERROR_RATE", 0),
+		FaultDelayMS:   envInt("FAULT_DELAY_MS", 0),
+		FaultSeed:      int64(envInt("FAULT_SEED", 1)),
+
+		ReplicaDSN:           os.Getenv("REPLICA_DATABASE_URL"),
+		MaxReplicaLagSeconds: envFloat("MAX_REPLICA_LAG_SECONDS", 10),
+
+		RetryAfterFormat: os.Getenv("RETRY_AFTER_FORMAT"),
+
+		HTTPReadTimeout:       flagDuration(envDurationLimit("HTTP_READ_TIMEOUT", 10*time.Second)),
+		HTTPReadHeaderTimeout: flagDuration(envDurationLimit("HTTP_READ_HEADER_TIMEOUT", 0)),
+		HTTPWriteTimeout:      flagDuration(envDurationLimit("HTTP_WRITE_TIMEOUT", 10*time.Second)),
+		HTTPIdleTimeout:       flagDuration(envDurationLimit("HTTP_IDLE_TIMEOUT", 60*time.Second)),
+		HTTPMaxHeaderBytes:    envInt("HTTP_MAX_HEADER_BYTES", http.DefaultMaxHeaderBytes),
+		HTTPKeepAlives:        !envBool("HTTP_DISABLE_KEEP_ALIVES"),
+
+		TrustedProxies:  splitList(os.Getenv("TRUSTED_PROXIES")),
+		RemoteIPHeaders: splitList(os.Getenv("REMOTE_IP_HEADERS")),
+
+		Port:    os.Getenv("PORT"),
+		Listen:  os.Getenv("LISTEN"),
+		Prefork: envBool("PREFORK"),
+
+		AdminPort: os.Getenv("ADMIN_PORT"),
+		GRPCPort:  os.Getenv("GRPC_PORT"),
+		HTTP3Port: os.Getenv("HTTP3_PORT"),
+		HTTP3Cert: os.Getenv("HTTP3_CERT"),
+		HTTP3Key:  os.Getenv("HTTP3_KEY"),
+		TLSCert:   os.Getenv("TLS_CERT"),
+		TLSKey:    os.Getenv("TLS_KEY"),
+
+		GOMAXPROCS: rt.GOMAXPROCS,
+		CPUQuota:   rt.CPUQuota,
+		GOGC:       rt.GOGC,
+		GOMemLimit: rt.GOMemLimit,
+		Ballast:    rt.Ballast,
+
+		JSONEngine: jsonEngine,
+		EasyJSON:   envBool("EASYJSON"),
+
+		PremarshalJSON: envBool("PREMARSHAL_JSON"),
+
+		PGOBuild:   pgoBuildProfile(),
+		PGOProfile: envBool("PGO_PROFILE"),
+
+		DBStartupTimeout:  flagDuration(envDuration("DB_STARTUP_TIMEOUT", 60*time.Second)),
+		AutoMigrate:       envBool("AUTO_MIGRATE"),
+		PoolPrewarm:       envBool("POOL_PREWARM"),
+		PoolRampDuration:  flagDuration(envDurationLimit("POOL_RAMP_DURATION", 0)),
+		PoolStatsInterval: flagDuration(envDuration("POOL_STATS_INTERVAL", 5*time.Second)),
+		PreShutdownDelay:  flagDuration(envDurationLimit("PRE_SHUTDOWN_DELAY", 0)),
+		DrainTimeout:      flagDuration(envDuration("DRAIN_TIMEOUT", 10*time.Second)),
+	}
+
+	f.Cache = f.CacheURL != ""
+	f.ReadReplica = f.ReplicaDSN != ""
+	f.TLS = f.TLSCert != ""
+	if f.Port == "" {
+		f.Port = "3005"
+	}
+
+	// One SO_REUSEPORT listener per P, so each accept loop can run in
+	// parallel with the others.
+	if envBool("REUSEPORT") {
+		f.ReusePortListeners = runtime.GOMAXPROCS(0)
+	}
+
+	disabled, err := parseRouteSpecs(os.Getenv("DISABLED_ROUTES"))
+	if err != nil {
+		return f, fmt.Errorf("DISABLED_ROUTES: %w", err)
+	}
+	f.DisabledRoutes = disabled
+
+	switch f.DataSource {
+	case "":
+		f.DataSource = "postgres"
+	case "postgres":
+	case "memory":
+		f.MemorySeedSize = envInt("MEMORY_SEED_SIZE", 10000)
+		if f.MemorySeedSize < 0 {
+			return f, fmt.Errorf("MEMORY_SEED_SIZE must be >= 0, got %d", f.MemorySeedSize)
+		}
+	default:
+		return f, fmt.Errorf(`DATA_SOURCE must be "postgres" or "memory", got %q`, f.DataSource)
+	}
+	if f.PGOProfile {
+		f.PGOProfilePath = os.Getenv("PGO_PROFILE_PATH")
+		if f.PGOProfilePath == "" {
+			f.PGOProfilePath = "default.pgo"
+		}
+	}
+	switch f.RetryAfterFormat {
+	case "":
+		f.RetryAfterFormat = "seconds"
+	case "seconds", "date":
+	default:
+		return f, fmt.Errorf(`RETRY_AFTER_FORMAT must be "seconds" or "date", got %q`, f.RetryAfterFormat)
+	}
+	if f.DBMaxOpenConns < 1 {
+		return f, fmt.Errorf("DB_MAX_OPEN_CONNS must be >= 1, got %d", f.DBMaxOpenConns)
+	}
+	if f.DBMaxIdleConns < 0 || f.DBMaxIdleConns > f.DBMaxOpenConns {
+		return f, fmt.Errorf("DB_MAX_IDLE_CONNS must be between 0 and DB_MAX_OPEN_CONNS (%d), got %d", f.DBMaxOpenConns, f.DBMaxIdleConns)
+	}
+	if f.DBBreakerFailures < 1 {
+		return f, fmt.Errorf("DB_BREAKER_FAILURES must be >= 1, got %d", f.DBBreakerFailures)
+	}
+	if f.DBBreakerProbes < 1 {
+		return f, fmt.Errorf("DB_BREAKER_PROBES must be >= 1, got %d", f.DBBreakerProbes)
+	}
+	if f.DBRetryMax < 1 || f.DBRetryMax > 10 {
+		return f, fmt.Errorf("DB_RETRY_MAX must be between 1 and 10, got %d", f.DBRetryMax)
+	}
+	if f.BackpressureFactor < 1 {
+		return f, fmt.Errorf("BACKPRESSURE_FACTOR must be >= 1, got %d", f.BackpressureFactor)
+	}
+	if f.MaxConcurrency < 0 {
+		return f, fmt.Errorf("MAX_CONCURRENCY must be >= 0, got %d", f.MaxConcurrency)
+	}
+	if f.MaxQueriesCount < 1 {
+		return f, fmt.Errorf("MAX_QUERIES_COUNT must be >= 1, got %d", f.MaxQueriesCount)
+	}
+	if f.MaxBodyBytes < 1 {
+		return f, fmt.Errorf("MAX_BODY_BYTES must be >= 1, got %d", f.MaxBodyBytes)
+	}
+	if f.HTTPMaxHeaderBytes < 1 {
+		return f, fmt.Errorf("HTTP_MAX_HEADER_BYTES must be >= 1, got %d", f.HTTPMaxHeaderBytes)
+	}
+	if f.StatementTimeout < 0 {
+		return f, fmt.Errorf("DB_STATEMENT_TIMEOUT_MS must be >= 0, got %d", f.StatementTimeout)
+	}
+	if f.ConnectionCheck < 0 {
+		return f, fmt.Errorf("DB_CONNECTION_CHECK_MS must be >= 0, got %d", f.ConnectionCheck)
+	}
+	if f.QueryWorkers < 0 {
+		return f, fmt.Errorf("QUERY_WORKERS must be >= 0, got %d", f.QueryWorkers)
+	}
+	for _, p := range f.TrustedProxies {
+		if _, _, err := net.ParseCIDR(p); err != nil && net.ParseIP(p) == nil {
+			return f, fmt.Errorf("TRUSTED_PROXIES: %q is neither an IP nor a CIDR", p)
+		}
+	}
+	if f.RemoteIPHeaders == nil {
+		f.RemoteIPHeaders = []string{"X-Forwarded-For", "X-Real-IP"}
+	}
+	if path, ok := strings.CutPrefix(f.Listen, "unix:"); ok {
+		if path == "" {
+			return f, fmt.Errorf("LISTEN: empty unix socket path")
+		}
+		// A path can only be bound once.
+		if f.ReusePortListeners > 0 || f.Prefork {
+			return f, fmt.Errorf("LISTEN=unix: cannot be combined with REUSEPORT or PREFORK")
+		}
+	} else if f.Listen != "" {
+		if _, _, err := net.SplitHostPort(f.Listen); err != nil {
+			return f, fmt.Errorf(`LISTEN must be "host:port" or "unix:<path>": %w`, err)
+		}
+	}
+	if f.TLS != (f.TLSKey != "") {
+		return f, fmt.Errorf("TLS_CERT and TLS_KEY must be set together")
+	}
+	if f.HTTP3Port != "" && (f.HTTP3Cert == "" || f.HTTP3Key == "") {
+		return f, fmt.Errorf("HTTP3_PORT requires HTTP3_CERT and HTTP3_KEY")
+	}
+	// Every child would bind the admin, gRPC and HTTP/3 ports too.
+	if f.Prefork && (f.AdminPort != "" || f.GRPCPort != "" || f.HTTP3Port != "") {
+		return f, fmt.Errorf("PREFORK cannot be combined with ADMIN_PORT, GRPC_PORT or HTTP3_PORT")
+	}
+	return f, nil
+}
+
+// splitList splits a comma-separated environment value, dropping blanks;
+// nil when there is nothing left.
+func splitList(raw string) []string {
+	var out []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// parseRouteSpecs parses a comma-separated list of "METHOD /path" specs,
+// normalizing the method to upper case. Whether each route exists is only
+// known once the router is built, so setupRouter checks that.
+func parseRouteSpecs(raw string) ([]string, error) {
+	specs := []string{}
+	for _, item := range strings.Split(raw, ",") {
+		fields := strings.Fields(item)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 || !strings.HasPrefix(fields[1], "/") {
+			return nil, fmt.Errorf(`invalid route spec %q, want "METHOD /path"`, strings.TrimSpace(item))
+		}
+		specs = append(specs, strings.ToUpper(fields[0])+" "+fields[1])
+	}
+	return specs, nil
+}
+
+// faultInjectionEnabled reports whether either fault rate is set.
+func (f Features) faultInjectionEnabled() bool {
+	return f.FaultRate > 0 || f.FaultErrorRate > 0
+}
+
+// GET /features — the resolved feature flags of this instance.
+func handleFeatures(f Features) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, f)
+	}
+}
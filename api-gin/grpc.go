@@ -0,0 +1,167 @@
+package main
+
+//go:generate buf generate
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"api-gin/userpb"
+)
+
+// ---------------------------------------------------------------------------
+// gRPC front-end (GRPC_PORT)
+// ---------------------------------------------------------------------------
+
+// userService exposes the UserRepository over gRPC, mirroring the REST
+// handlers so both fronts measure the same data-access work.
+type userService struct {
+	userpb.UnimplementedUserServiceServer
+	repo UserRepository
+}
+
+func newGRPCServer(repo UserRepository) *grpc.Server {
+	srv := grpc.NewServer()
+	userpb.RegisterUserServiceServer(srv, &userService{repo: repo})
+	return srv
+}
+
+// grpcError maps repository errors onto gRPC status codes, the counterpart
+// of respondError for the REST handlers.
+func grpcError(err error) error {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return status.Error(codes.NotFound, "User not found")
+	case errors.Is(err, ErrEmailTaken):
+		return status.Error(codes.AlreadyExists, "Email already in use")
+	case errors.Is(err, ErrVersionMismatch):
+		return status.Error(codes.FailedPrecondition, "User has been modified")
+	case errors.Is(err, ErrNullValue):
+		return status.Errorf(codes.InvalidArgument, "Missing required value: %v", err)
+	case errors.Is(err, ErrInvalidReference):
+		return status.Errorf(codes.FailedPrecondition, "Referenced row constraint violated: %v", err)
+	case errors.Is(err, ErrPoolExhausted):
+		return status.Error(codes.Unavailable, "Database pool exhausted")
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, "Database query timed out")
+	default:
+		return status.Errorf(codes.Internal, "Database error: %v", err)
+	}
+}
+
+func toProtoUser(u User) *userpb.User {
+	pu := &userpb.User{
+		Id:        int32(u.ID),
+		Name:      u.Name,
+		Email:     u.Email,
+		CreatedAt: timestamppb.New(u.CreatedAt),
+	}
+	if u.Age != nil {
+		age := int32(*u.Age)
+		pu.Age = &age
+	}
+	return pu
+}
+
+// intPtr widens an optional proto int32 to the *int used by the domain types.
+func intPtr(v *int32) *int {
+	if v == nil {
+		return nil
+	}
+	n := int(*v)
+	return &n
+}
+
+func (s *userService) RandomUser(ctx context.Context, _ *emptypb.Empty) (*userpb.User, error) {
+	user, err := s.repo.Random(ctx, RandomByID)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return toProtoUser(user), nil
+}
+
+func (s *userService) GetUser(ctx context.Context, req *userpb.GetUserRequest) (*userpb.User, error) {
+	if req.GetId() < 1 {
+		return nil, status.Error(codes.InvalidArgument, "Invalid user ID")
+	}
+	user, err := s.repo.GetByID(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return toProtoUser(user), nil
+}
+
+func (s *userService) ListUsers(ctx context.Context, req *userpb.ListUsersRequest) (*userpb.ListUsersResponse, error) {
+	limit := int(req.GetLimit())
+	offset := max(int(req.GetOffset()), 0)
+	if limit != 0 {
+		limit = min(max(limit, 1), 100)
+	}
+
+	users, err := s.repo.List(ctx, UserOrder{}, limit, offset)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	resp := &userpb.ListUsersResponse{Users: make([]*userpb.User, len(users))}
+	for i, u := range users {
+		resp.Users[i] = toProtoUser(u)
+	}
+	if limit != 0 {
+		total, err := s.repo.Count(ctx)
+		if err != nil {
+			return nil, grpcError(err)
+		}
+		resp.Total = int32(total)
+	}
+	return resp, nil
+}
+
+func (s *userService) CreateUser(ctx context.Context, req *userpb.CreateUserRequest) (*userpb.User, error) {
+	if req.GetName() == "" || req.GetEmail() == "" {
+		return nil, status.Error(codes.InvalidArgument, "name and email are required")
+	}
+	user, err := s.repo.Create(ctx, CreateUserRequest{
+		Name:  req.GetName(),
+		Email: req.GetEmail(),
+		Age:   intPtr(req.Age),
+	})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return toProtoUser(user), nil
+}
+
+func (s *userService) UpdateUser(ctx context.Context, req *userpb.UpdateUserRequest) (*userpb.User, error) {
+	if req.GetId() < 1 {
+		return nil, status.Error(codes.InvalidArgument, "Invalid user ID")
+	}
+	if req.Name == nil && req.Email == nil && req.Age == nil {
+		return nil, status.Error(codes.InvalidArgument, "At least one field (name, email, age) is required")
+	}
+	user, err := s.repo.Update(ctx, int(req.GetId()), UpdateUserRequest{
+		Name:  req.Name,
+		Email: req.Email,
+		Age:   intPtr(req.Age),
+	}, 0)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return toProtoUser(user), nil
+}
+
+func (s *userService) DeleteUser(ctx context.Context, req *userpb.DeleteUserRequest) (*emptypb.Empty, error) {
+	if req.GetId() < 1 {
+		return nil, status.Error(codes.InvalidArgument, "Invalid user ID")
+	}
+	if err := s.repo.Delete(ctx, int(req.GetId())); err != nil {
+		return nil, grpcError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
@@ -0,0 +1,886 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ---------------------------------------------------------------------------
+// Handlers
+// ---------------------------------------------------------------------------
+
+// respondError maps repository errors onto the problem details envelope.
+// notFound is the message used for ErrNotFound.
+func respondError(c *gin.Context, err error, notFound string) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		errorJSON(c, http.StatusNotFound, notFound, "")
+	case errors.Is(err, ErrEmailTaken):
+		errorJSON(c, http.StatusConflict, "Email already in use", "")
+	case errors.Is(err, ErrVersionMismatch):
+		errorJSON(c, http.StatusPreconditionFailed, "User has been modified", "")
+	case errors.Is(err, ErrNullValue):
+		errorJSON(c, http.StatusUnprocessableEntity, "Missing required value", err.Error())
+	case errors.Is(err, ErrInvalidReference):
+		errorJSON(c, http.StatusConflict, "Referenced row constraint violated", err.Error())
+	case errors.Is(err, ErrPoolExhausted):
+		// Overload, not a database failure: tell the client to back off.
+		setRetryAfter(c, retryAfterDelay)
+		problemJSON(c, http.StatusServiceUnavailable, RetryErrorResponse{
+			Details:    newProblem(c, http.StatusServiceUnavailable, "Database pool exhausted", ""),
+			RetryAfter: retryAfterSeconds,
+		})
+	case errors.Is(err, ErrCircuitOpen):
+		// DB_BREAKER tripped: the database was not even tried.
+		setRetryAfter(c, retryAfterDelay)
+		problemJSON(c, http.StatusServiceUnavailable, RetryErrorResponse{
+			Details:    newProblem(c, http.StatusServiceUnavailable, "Database unavailable", ""),
+			RetryAfter: retryAfterSeconds,
+		})
+	case errors.Is(err, context.DeadlineExceeded):
+		// DB_QUERY_TIMEOUT expired with the pool not saturated: the query
+		// itself was too slow.
+		errorJSON(c, http.StatusGatewayTimeout, "Database query timed out", "")
+	default:
+		errorJSON(c, http.StatusInternalServerError, "Database error", err.Error())
+	}
+}
+
+// retryAfterDelay is the back-off suggested by every 503 and 429, both in
+// the Retry-After header and, as retryAfterSeconds, in the retry_after
+// member of the problem body.
+const (
+	retryAfterDelay   = time.Second
+	retryAfterSeconds = int(retryAfterDelay / time.Second)
+)
+
+// retryAfterAsDate selects the HTTP-date form of Retry-After
+// (RETRY_AFTER_FORMAT=date) over delta-seconds; set once by setupRouter.
+var retryAfterAsDate bool
+
+// setRetryAfter writes the Retry-After hint for d, either as delta-seconds
+// (rounded up, at least 1) or as an HTTP-date d from now.
+func setRetryAfter(c *gin.Context, d time.Duration) {
+	if retryAfterAsDate {
+		c.Header("Retry-After", time.Now().Add(d).UTC().Format(http.TimeFormat))
+		return
+	}
+	secs := max(int((d+time.Second-1)/time.Second), 1)
+	c.Header("Retry-After", strconv.Itoa(secs))
+}
+
+// jsonBinder decodes and validates a JSON request body into obj.
+type jsonBinder func(c *gin.Context, obj any) error
+
+// newJSONBinder returns Gin's lenient binding, or with strict set
+// (STRICT_JSON=1) a decoder that rejects unknown fields, so a typo such as
+// "agee" fails with 400 instead of silently storing a null age.
+func newJSONBinder(strict bool) jsonBinder {
+	if !strict {
+		return func(c *gin.Context, obj any) error {
+			return c.ShouldBindJSON(obj)
+		}
+	}
+	return func(c *gin.Context, obj any) error {
+		if c.Request.Body == nil {
+			return errors.New("invalid request")
+		}
+		dec := json.NewDecoder(c.Request.Body)
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(obj); err != nil {
+			return err
+		}
+		return binding.Validator.ValidateStruct(obj)
+	}
+}
+
+// GET / — identity plus machine-readable capabilities for harnesses that
+// auto-discover targets. The route table is read once, on first request,
+// when registration is complete, and the whole body is marshaled then.
+func handleRoot(r *gin.Engine, poolSize int) gin.HandlerFunc {
+	var (
+		once sync.Once
+		body staticJSON
+	)
+
+	return func(c *gin.Context) {
+		once.Do(func() {
+			var routes []string
+			for _, ri := range r.Routes() {
+				routes = append(routes, ri.Method+" "+ri.Path)
+			}
+			sort.Strings(routes)
+
+			body = mustStaticJSON(RootResponse{
+				Message:   "Gin API",
+				Framework: "gin",
+				Runtime:   "go",
+				Capabilities: Capabilities{
+					Routes:     routes,
+					GoVersion:  runtime.Version(),
+					GOMAXPROCS: runtime.GOMAXPROCS(0),
+					DBPoolSize: poolSize,
+				},
+			})
+		})
+
+		body.write(c)
+	}
+}
+
+// GET /readyz — readiness probe; 503 once shutdown draining has begun so
+// load balancers deregister the instance while it keeps serving.
+func handleReadyz(draining *atomic.Bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if draining.Load() {
+			setRetryAfter(c, retryAfterDelay)
+			c.JSON(http.StatusServiceUnavailable, StatusResponse{Status: "draining"})
+			return
+		}
+		c.JSON(http.StatusOK, StatusResponse{Status: "ready"})
+	}
+}
+
+// GET /readyz/replica — replication lag of the read replica, in seconds.
+// 503 when the lag exceeds maxLag, or when the replica has not replayed any
+// transaction yet (pg_last_xact_replay_timestamp() is NULL), so benchmark
+// reads can be gated on acceptable staleness.
+func handleReplicaReadyz(replica *pgxpool.Pool, maxLag float64) gin.HandlerFunc {
+	const query = `SELECT EXTRACT(EPOCH FROM (now() - pg_last_xact_replay_timestamp()))::float8`
+
+	return func(c *gin.Context) {
+		var lag *float64
+		if err := replica.QueryRow(c.Request.Context(), query).Scan(&lag); err != nil {
+			setRetryAfter(c, retryAfterDelay)
+			c.JSON(http.StatusServiceUnavailable, StatusResponse{Status: "unavailable", Detail: err.Error()})
+			return
+		}
+		if lag == nil {
+			setRetryAfter(c, retryAfterDelay)
+			c.JSON(http.StatusServiceUnavailable, ReplicaLagResponse{Status: "no_replay", MaxLagSeconds: maxLag})
+			return
+		}
+		if *lag > maxLag {
+			setRetryAfter(c, retryAfterDelay)
+			c.JSON(http.StatusServiceUnavailable, ReplicaLagResponse{Status: "lagging", LagSeconds: lag, MaxLagSeconds: maxLag})
+			return
+		}
+		c.JSON(http.StatusOK, ReplicaLagResponse{Status: "ready", LagSeconds: lag, MaxLagSeconds: maxLag})
+	}
+}
+
+// handleNotFound answers unregistered paths with the problem envelope
+// instead of Gin's plain-text "404 page not found".
+func handleNotFound(c *gin.Context) {
+	errorJSON(c, http.StatusNotFound, "Not found", "")
+}
+
+// handleMethodNotAllowed answers a known path hit with the wrong method.
+// Gin has already set the Allow header from the methods registered there.
+func handleMethodNotAllowed(c *gin.Context) {
+	errorJSON(c, http.StatusMethodNotAllowed, "Method not allowed", "")
+}
+
+// helloMessage is the fixed GET /json payload.
+var helloMessage = MessageResponse{
+	Message:   "Hello, World!",
+	Framework: "gin",
+}
+
+// GET /json — serialized per request, as the JSON test requires, unless
+// premarshal (PREMARSHAL_JSON=1) serves bytes encoded once at startup.
+func handleJSON(premarshal bool) gin.HandlerFunc {
+	if premarshal {
+		body := mustStaticJSON(helloMessage)
+		return body.write
+	}
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, helloMessage)
+	}
+}
+
+// The /plaintext response is fixed, so its body and header values are built
+// once; assigning the prebuilt slices directly into the header map avoids the
+// per-request allocations of Header().Set.
+var (
+	plaintextBody          = []byte("Hello, World!")
+	plaintextContentType   = []string{"text/plain; charset=utf-8"}
+	plaintextContentLength = []string{strconv.Itoa(len(plaintextBody))}
+)
+
+// GET /plaintext — TechEmpower-style minimal-serialization baseline
+func handlePlaintext(c *gin.Context) {
+	h := c.Writer.Header()
+	h["Content-Type"] = plaintextContentType
+	h["Content-Length"] = plaintextContentLength
+	c.Writer.WriteHeader(http.StatusOK)
+	c.Writer.Write(plaintextBody)
+}
+
+// maxSlowMS caps GET /slow?ms.
+const maxSlowMS = 60000
+
+// GET /slow?ms=N — holds a pooled connection in pg_sleep for N ms (0-60000,
+// default 1000), to check that an abandoned request does not leave its query
+// running in Postgres: a client disconnect or DB_QUERY_TIMEOUT cancels the
+// request context, pgx drops the connection and, with DB_CONNECTION_CHECK_MS
+// set, Postgres aborts the sleep within that interval. TestSlowQueryCancel
+// checks both cases against pg_stat_activity. Only with DATA_SOURCE=postgres.
+func handleSlow(db *pgxpool.Pool, timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ms := 1000
+		if raw := c.Query("ms"); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil || n < 0 || n > maxSlowMS {
+				errorJSON(c, http.StatusBadRequest, "Invalid ms", "must be between 0 and "+strconv.Itoa(maxSlowMS))
+				return
+			}
+			ms = n
+		}
+
+		ctx := c.Request.Context()
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		if _, err := db.Exec(ctx, "SELECT pg_sleep($1)", float64(ms)/1000); err != nil {
+			respondError(c, err, "")
+			return
+		}
+		c.JSON(http.StatusOK, SlowResponse{SleptMS: ms})
+	}
+}
+
+// GET /client-ip — the address Gin resolves for the client next to the
+// socket peer, to check TRUSTED_PROXIES behind a load balancer: client_ip
+// only differs from remote_ip when the peer is a trusted proxy.
+func handleClientIP(headers []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		resp := ClientIPResponse{ClientIP: c.ClientIP(), RemoteIP: c.RemoteIP()}
+		for _, name := range headers {
+			if v := c.GetHeader(name); v != "" {
+				if resp.Headers == nil {
+					resp.Headers = make(map[string]string, len(headers))
+				}
+				resp.Headers[name] = v
+			}
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// POST /echo — decodes a JSON object and re-encodes it, to check the JSON
+// parse/serialize round trip in isolation from the database. Numbers are
+// kept as json.Number so large integers and decimals come back verbatim,
+// HTML characters are not escaped, and object keys come back sorted (Go map
+// encoding order). Bodies over maxBytes (MAX_BODY_BYTES) get 413.
+func handleEcho(maxBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
+
+		dec := json.NewDecoder(c.Request.Body)
+		dec.UseNumber()
+		var body map[string]any
+		err := dec.Decode(&body)
+		if err == nil && dec.More() {
+			err = errors.New("unexpected data after JSON object")
+		}
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				problemJSON(c, http.StatusRequestEntityTooLarge, BodyTooLargeResponse{
+					Details: newProblem(c, http.StatusRequestEntityTooLarge, "Request body too large", ""),
+					Limit:   maxBytes,
+				})
+				return
+			}
+			errorJSON(c, http.StatusBadRequest, "Invalid JSON body", err.Error())
+			return
+		}
+
+		c.PureJSON(http.StatusOK, body)
+	}
+}
+
+// GET /db — single random user from the database
+// By default a primary-key lookup of an id drawn from the seeded range;
+// ?mode=scan keeps ORDER BY RANDOM(), which scans and sorts the whole table.
+// ?sample=system uses TABLESAMPLE SYSTEM block sampling instead: cheap on
+// large tables, but statistically skewed (see RandomSampled).
+func handleDB(repo UserRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		mode, ok := parseRandomMode(c.Query("mode"))
+		if !ok {
+			errorJSON(c, http.StatusBadRequest, "Invalid mode", "")
+			return
+		}
+
+		var user User
+		var err error
+		if c.Query("sample") == "system" {
+			user, err = repo.RandomSampled(c.Request.Context())
+		} else {
+			user, err = repo.Random(c.Request.Context(), mode)
+		}
+		if err != nil {
+			respondError(c, err, "No users found")
+			return
+		}
+		renderJSON(c, http.StatusOK, user)
+	}
+}
+
+// GET /queries?count=N — N random users in a single query (1-maxCount, default 1)
+// Users are looked up by id unless ?mode=scan, as in /db. With ?conn=pinned,
+// N single-row queries run on one dedicated connection to measure the
+// effect of connection affinity. With ?conn=per-row, N single-row queries
+// each borrow a pool connection; workers (QUERY_WORKERS) bounds how many run
+// at once, 0 running them one after another. With ?conn=batched, the same N
+// single-row queries go out in one round trip.
+func handleQueries(repo UserRepository, maxCount, workers int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		count := parseCount(c.Query("count"), maxCount)
+		mode, ok := parseRandomMode(c.Query("mode"))
+		if !ok {
+			errorJSON(c, http.StatusBadRequest, "Invalid mode", "")
+			return
+		}
+
+		var users []User
+		var err error
+		switch c.Query("conn") {
+		case "pinned":
+			users, err = repo.RandomPinned(c.Request.Context(), count, mode)
+		case "per-row":
+			users, err = randomPerRow(c.Request.Context(), repo, count, workers, mode)
+		case "batched":
+			users, err = repo.RandomBatched(c.Request.Context(), count, mode)
+		default:
+			users, err = repo.RandomN(c.Request.Context(), count, mode)
+		}
+		if err != nil {
+			respondError(c, err, "No users found")
+			return
+		}
+
+		renderJSON(c, http.StatusOK, Users(users))
+		// The body is fully written, so the slice can be reused.
+		putUsers(users)
+	}
+}
+
+// randomPerRow runs n single-row Random queries on at most workers
+// goroutines. Each worker writes into its own slot, so results come back in
+// request order. The first error cancels the work still pending, as does
+// the request context.
+func randomPerRow(ctx context.Context, repo UserRepository, n, workers int, mode RandomMode) ([]User, error) {
+	users := getUsers(n)[:n]
+	if workers <= 0 {
+		for i := range users {
+			user, err := repo.Random(ctx, mode)
+			if err != nil {
+				return nil, err
+			}
+			users[i] = user
+		}
+		return users, nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	jobs := make(chan int)
+	for w := 0; w < min(workers, n); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				user, err := repo.Random(ctx, mode)
+				if err != nil {
+					errOnce.Do(func() {
+						firstErr = err
+						cancel()
+					})
+					continue
+				}
+				users[i] = user
+			}
+		}()
+	}
+
+feed:
+	for i := 0; i < n; i++ {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// PaginatedUsers is the response shape when pagination params are provided.
+type PaginatedUsers struct {
+	Data   []User `json:"data"`
+	Total  int    `json:"total"`
+	Limit  int    `json:"limit"`
+	Offset int    `json:"offset"`
+}
+
+// parsePage reads ?limit (1-100, default 20 when unparseable) and ?offset
+// (>=0). paged is false when no limit was requested.
+func parsePage(c *gin.Context) (limit, offset int, paged bool) {
+	limitStr := c.Query("limit")
+	if limitStr == "" {
+		return 0, 0, false
+	}
+
+	limit = 20
+	if n, err := strconv.Atoi(limitStr); err == nil {
+		limit = n
+	}
+	if limit < 1 {
+		limit = 1
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	if offsetStr := c.Query("offset"); offsetStr != "" {
+		if n, err := strconv.Atoi(offsetStr); err == nil && n > 0 {
+			offset = n
+		}
+	}
+	return limit, offset, true
+}
+
+// ndjsonContentType is the media type for newline-delimited JSON.
+const ndjsonContentType = "application/x-ndjson"
+
+// wantsNDJSON reports whether the client asked for newline-delimited JSON.
+func wantsNDJSON(c *gin.Context) bool {
+	return strings.Contains(c.GetHeader("Accept"), ndjsonContentType)
+}
+
+// streamUsersNDJSON writes one JSON object per line, flushing each row as it
+// arrives from the cursor instead of buffering the whole array.
+func streamUsersNDJSON(c *gin.Context, repo UserRepository, order UserOrder, limit, offset int) {
+	enc := json.NewEncoder(c.Writer)
+	started := false
+
+	err := repo.Stream(c.Request.Context(), order, limit, offset, func(user User) error {
+		if !started {
+			c.Header("Content-Type", ndjsonContentType)
+			c.Status(http.StatusOK)
+			started = true
+		}
+		// Encode appends the trailing newline.
+		if err := enc.Encode(user); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+		return nil
+	})
+	if err == nil && !started {
+		c.Header("Content-Type", ndjsonContentType)
+		c.Status(http.StatusOK)
+		return
+	}
+	if err != nil {
+		if !started {
+			respondError(c, err, "No users found")
+			return
+		}
+		// Headers are already sent, so failures can only end the stream.
+		log.Printf("ndjson: stream aborted: %v", err)
+		c.Abort()
+	}
+}
+
+// GET /users — all users ordered by id
+// Optional: ?limit=N (1-100) and ?offset=N (>=0) for pagination, and
+// ?sort=<column> (or -<column> for descending) with id as the tie-break.
+// With Accept: application/x-ndjson the rows are streamed one per line.
+func handleGetUsers(repo UserRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, offset, paged := parsePage(c)
+
+		order, ok := parseUserOrder(c.Query("sort"))
+		if !ok {
+			errorJSON(c, http.StatusBadRequest, "Invalid sort column", "")
+			return
+		}
+
+		if wantsNDJSON(c) {
+			streamUsersNDJSON(c, repo, order, limit, offset)
+			return
+		}
+
+		if !paged {
+			users, err := repo.List(c.Request.Context(), order, 0, 0)
+			if err != nil {
+				respondError(c, err, "No users found")
+				return
+			}
+			renderJSON(c, http.StatusOK, Users(users))
+			return
+		}
+
+		// Run COUNT and paginated SELECT concurrently.
+		type countResult struct {
+			total int
+			err   error
+		}
+		type rowsResult struct {
+			users []User
+			err   error
+		}
+
+		countCh := make(chan countResult, 1)
+		rowsCh := make(chan rowsResult, 1)
+
+		go func() {
+			total, err := repo.Count(c.Request.Context())
+			countCh <- countResult{total, err}
+		}()
+
+		go func() {
+			users, err := repo.List(c.Request.Context(), order, limit, offset)
+			rowsCh <- rowsResult{users, err}
+		}()
+
+		cr := <-countCh
+		rr := <-rowsCh
+		if cr.err != nil {
+			respondError(c, cr.err, "No users found")
+			return
+		}
+		if rr.err != nil {
+			respondError(c, rr.err, "No users found")
+			return
+		}
+
+		c.JSON(http.StatusOK, PaginatedUsers{
+			Data:   rr.users,
+			Total:  cr.total,
+			Limit:  limit,
+			Offset: offset,
+		})
+	}
+}
+
+// GET /users.csv — stream the users table as CSV, one row per cursor step.
+// Exercises a non-JSON serialization path; a nullable age is an empty field.
+func handleUsersCSV(repo UserRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		w := csv.NewWriter(c.Writer)
+		record := make([]string, 5)
+		started := false
+
+		start := func() {
+			c.Header("Content-Type", "text/csv; charset=utf-8")
+			c.Header("Content-Disposition", `attachment; filename="users.csv"`)
+			c.Status(http.StatusOK)
+			_ = w.Write([]string{"id", "name", "email", "age", "created_at"})
+			started = true
+		}
+
+		err := repo.Stream(ctx, UserOrder{}, 0, 0, func(user User) error {
+			if !started {
+				start()
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			record[0] = strconv.Itoa(user.ID)
+			record[1] = user.Name
+			record[2] = ""
+			if user.Email != nil {
+				record[2] = *user.Email
+			}
+			record[3] = ""
+			if user.Age != nil {
+				record[3] = strconv.Itoa(*user.Age)
+			}
+			record[4] = user.CreatedAt.Format(time.RFC3339Nano)
+			return w.Write(record)
+		})
+		if err != nil {
+			if !started {
+				respondError(c, err, "No users found")
+				return
+			}
+			// Headers are already sent, so failures can only end the stream.
+			log.Printf("users.csv: stream aborted: %v", err)
+			return
+		}
+		if !started {
+			start()
+		}
+		// The last buffered rows reach the client here; a failure now, like
+		// one mid-stream, can only be logged.
+		w.Flush()
+		if err := w.Error(); err != nil {
+			log.Printf("users.csv: stream aborted: %v", err)
+		}
+	}
+}
+
+// GET /users/stats — aggregate age statistics computed in one query
+func handleUserStats(repo UserRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stats, err := repo.Stats(c.Request.Context())
+		if err != nil {
+			respondError(c, err, "No users found")
+			return
+		}
+		c.JSON(http.StatusOK, stats)
+	}
+}
+
+// etagFor derives the strong ETag of a user row from its version column.
+func etagFor(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// parseIfMatch extracts the expected row version from an If-Match header.
+// It returns wildcard=true for "*" (no version constraint). Weak or
+// non-numeric tags can never match a version, so they yield ok=false.
+func parseIfMatch(raw string) (version int, wildcard bool, ok bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "*" {
+		return 0, true, true
+	}
+	if len(raw) < 3 || raw[0] != '"' || raw[len(raw)-1] != '"' {
+		return 0, false, false
+	}
+	n, err := strconv.Atoi(raw[1 : len(raw)-1])
+	if err != nil || n < 1 {
+		return 0, false, false
+	}
+	return n, false, true
+}
+
+// GET /users/:id — single user by ID, with its ETag
+func handleGetUser(repo UserRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseID(c.Param("id"))
+		if !ok {
+			errorJSON(c, http.StatusBadRequest, "Invalid user ID", "")
+			return
+		}
+
+		user, err := repo.GetByID(c.Request.Context(), id)
+		if err != nil {
+			respondError(c, err, "User not found")
+			return
+		}
+
+		c.Header("ETag", etagFor(user.Version))
+		renderJSON(c, http.StatusOK, user)
+	}
+}
+
+// wantsMinimalReturn reports whether the client asked for the id-only
+// response, via ?fields=id or Prefer: return=minimal (RFC 7240).
+func wantsMinimalReturn(c *gin.Context) bool {
+	if c.Query("fields") == "id" {
+		return true
+	}
+	for _, pref := range strings.Split(c.GetHeader("Prefer"), ",") {
+		if strings.EqualFold(strings.TrimSpace(pref), "return=minimal") {
+			return true
+		}
+	}
+	return false
+}
+
+// POST /users — create a user, respond 201 with the created object
+// With ?fields=id or Prefer: return=minimal only {"id":N} is returned (plus a
+// Location header), isolating insert cost from response serialization.
+func handleCreateUser(repo UserRepository, bind jsonBinder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req CreateUserRequest
+		if err := bind(c, &req); err != nil {
+			errorJSON(c, http.StatusBadRequest, err.Error(), "")
+			return
+		}
+
+		if wantsMinimalReturn(c) {
+			id, err := repo.CreateMinimal(c.Request.Context(), req)
+			if err != nil {
+				respondError(c, err, "User not found")
+				return
+			}
+
+			c.Header("Location", "/users/"+strconv.Itoa(id))
+			c.Header("Preference-Applied", "return=minimal")
+			c.JSON(http.StatusCreated, CreatedIDResponse{ID: id})
+			return
+		}
+
+		user, err := repo.Create(c.Request.Context(), req)
+		if err != nil {
+			respondError(c, err, "User not found")
+			return
+		}
+
+		c.JSON(http.StatusCreated, user)
+	}
+}
+
+// PUT /users/:id — update an existing user, respond with the updated object
+//
+// Optimistic concurrency: when If-Match carries the ETag returned by
+// GET /users/:id, the UPDATE is conditioned on the row version and a stale
+// tag yields 412 Precondition Failed instead of overwriting.
+func handleUpdateUser(repo UserRepository, bind jsonBinder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseID(c.Param("id"))
+		if !ok {
+			errorJSON(c, http.StatusBadRequest, "Invalid user ID", "")
+			return
+		}
+
+		var req UpdateUserRequest
+		if err := bind(c, &req); err != nil {
+			errorJSON(c, http.StatusBadRequest, err.Error(), "")
+			return
+		}
+
+		if req.Name == nil && req.Email == nil && req.Age == nil {
+			errorJSON(c, http.StatusBadRequest, "At least one field (name, email, age) is required", "")
+			return
+		}
+
+		// An unparseable tag can never match, so it is sent as version -1:
+		// still conditional, yielding 412 (or 404 when the row is gone).
+		version := 0
+		if ifMatch := c.GetHeader("If-Match"); ifMatch != "" {
+			v, wildcard, ok := parseIfMatch(ifMatch)
+			switch {
+			case wildcard:
+			case ok:
+				version = v
+			default:
+				version = -1
+			}
+		}
+
+		updated, err := repo.Update(c.Request.Context(), id, req, version)
+		if err != nil {
+			respondError(c, err, "User not found")
+			return
+		}
+
+		c.Header("ETag", etagFor(updated.Version))
+		c.JSON(http.StatusOK, updated)
+	}
+}
+
+// maxBulkUpdate caps the number of elements accepted by PUT /users.
+const maxBulkUpdate = 100
+
+// PUT /users — apply an array of partial updates in one transaction
+// Each element needs an id and at least one of name, email, age. Either all
+// updates apply, returning the updated users in request order, or none do:
+// the offending id is reported with 404 (missing) or 409 (email in use).
+func handleBulkUpdateUsers(repo UserRepository, bind jsonBinder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqs []BulkUpdateUserRequest
+		if err := bind(c, &reqs); err != nil {
+			errorJSON(c, http.StatusBadRequest, err.Error(), "")
+			return
+		}
+
+		if len(reqs) == 0 || len(reqs) > maxBulkUpdate {
+			errorJSON(c, http.StatusBadRequest, fmt.Sprintf("Expected between 1 and %d updates", maxBulkUpdate), "")
+			return
+		}
+		for i, req := range reqs {
+			if req.ID < 1 {
+				problemJSON(c, http.StatusBadRequest, BulkIndexErrorResponse{
+					Details: newProblem(c, http.StatusBadRequest, "Invalid user ID", ""),
+					Index:   i,
+				})
+				return
+			}
+			if req.Name == nil && req.Email == nil && req.Age == nil {
+				problemJSON(c, http.StatusBadRequest, BulkIndexErrorResponse{
+					Details: newProblem(c, http.StatusBadRequest, "At least one field (name, email, age) is required", ""),
+					Index:   i,
+				})
+				return
+			}
+		}
+
+		users, err := repo.UpdateMany(c.Request.Context(), reqs)
+		var bulkErr *BulkUpdateError
+		switch {
+		case err == nil:
+			c.JSON(http.StatusOK, users)
+		case errors.As(err, &bulkErr) && errors.Is(err, ErrNotFound):
+			problemJSON(c, http.StatusNotFound, BulkIDErrorResponse{
+				Details: newProblem(c, http.StatusNotFound, "User not found", ""),
+				ID:      bulkErr.ID,
+			})
+		case errors.As(err, &bulkErr) && errors.Is(err, ErrEmailTaken):
+			problemJSON(c, http.StatusConflict, BulkIDErrorResponse{
+				Details: newProblem(c, http.StatusConflict, "Email already in use", ""),
+				ID:      bulkErr.ID,
+			})
+		default:
+			respondError(c, err, "User not found")
+		}
+	}
+}
+
+// DELETE /users/:id — remove a user, respond 204 on success
+func handleDeleteUser(repo UserRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseID(c.Param("id"))
+		if !ok {
+			errorJSON(c, http.StatusBadRequest, "Invalid user ID", "")
+			return
+		}
+
+		if err := repo.Delete(c.Request.Context(), id); err != nil {
+			respondError(c, err, "User not found")
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
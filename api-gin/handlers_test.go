@@ -0,0 +1,591 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"problem"
+)
+
+// newTestRouter builds the public router the way main does, from Features
+// loaded out of env on top of DATA_SOURCE=memory with 10 seeded users, and
+// returns it with the repository behind it.
+func newTestRouter(t *testing.T, env map[string]string) (*gin.Engine, *memoryUserRepository) {
+	t.Helper()
+	f := testFeatures(t, env)
+	repo := newMemoryUserRepository(f.MemorySeedSize)
+	return testRouter(repo, f), repo
+}
+
+// testFeatures loads Features from env on top of DATA_SOURCE=memory and
+// MEMORY_SEED_SIZE=10.
+func testFeatures(t *testing.T, env map[string]string) Features {
+	t.Helper()
+	t.Setenv("DATA_SOURCE", "memory")
+	t.Setenv("MEMORY_SEED_SIZE", "10")
+	for k, v := range env {
+		t.Setenv(k, v)
+	}
+	f, err := loadFeatures()
+	if err != nil {
+		t.Fatalf("loadFeatures: %v", err)
+	}
+	return f
+}
+
+// testRouter builds the public router over repo, with no pool, cache,
+// replica or breaker.
+func testRouter(repo UserRepository, f Features) *gin.Engine {
+	var draining atomic.Bool
+	var requests requestTracker
+	return setupRouter(repo, nil, nil, nil, nil, &draining, &requests, f)
+}
+
+// doRequest runs one request through r; header holds name/value pairs.
+func doRequest(r http.Handler, method, path, body string, header ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestUpdateUserIfMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		ifMatch  string
+		want     int
+		wantETag string
+	}{
+		{"no header", "/users/1", "", http.StatusOK, `"2"`},
+		{"current tag", "/users/1", `"1"`, http.StatusOK, `"2"`},
+		{"wildcard", "/users/1", "*", http.StatusOK, `"2"`},
+		{"stale tag", "/users/1", `"7"`, http.StatusPreconditionFailed, ""},
+		{"unparseable tag", "/users/1", "v1", http.StatusPreconditionFailed, ""},
+		{"weak tag", "/users/1", `W/"1"`, http.StatusPreconditionFailed, ""},
+		{"missing row", "/users/999", `"1"`, http.StatusNotFound, ""},
+		{"missing row, unparseable tag", "/users/999", "v1", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, repo := newTestRouter(t, nil)
+			var header []string
+			if tt.ifMatch != "" {
+				header = []string{"If-Match", tt.ifMatch}
+			}
+			w := doRequest(r, http.MethodPut, tt.path, `{"name":"Renamed"}`, header...)
+
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+			if got := w.Header().Get("ETag"); got != tt.wantETag {
+				t.Errorf("ETag = %q, want %q", got, tt.wantETag)
+			}
+			// A refused update leaves the row as it was.
+			if tt.want == http.StatusPreconditionFailed {
+				if u := repo.users[1]; u.Name == "Renamed" || u.Version != 1 {
+					t.Errorf("row changed on %d: %+v", tt.want, u)
+				}
+			}
+		})
+	}
+}
+
+func TestGetUserETag(t *testing.T) {
+	r, repo := newTestRouter(t, nil)
+	u := repo.users[1]
+	u.Version = 3
+	repo.users[1] = u
+
+	w := doRequest(r, http.MethodGet, "/users/1", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+	if got := w.Header().Get("ETag"); got != `"3"` {
+		t.Errorf("ETag = %q, want %q", got, `"3"`)
+	}
+}
+
+func TestPanicRecovery(t *testing.T) {
+	tests := []struct {
+		appEnv     string
+		want       int
+		wantDetail bool
+	}{
+		{"test", http.StatusInternalServerError, true},
+		{"production", http.StatusNotFound, false},
+	}
+	for _, tt := range tests {
+		t.Run("APP_ENV="+tt.appEnv, func(t *testing.T) {
+			r, _ := newTestRouter(t, map[string]string{"APP_ENV": tt.appEnv})
+			w := doRequest(r, http.MethodGet, "/debug/panic", "", "X-Request-ID", "req-123")
+
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+			if tt.want == http.StatusNotFound {
+				return
+			}
+			if ct := w.Header().Get("Content-Type"); ct != problem.ContentType {
+				t.Errorf("Content-Type = %q, want %q", ct, problem.ContentType)
+			}
+			var body map[string]any
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode %q: %v", w.Body, err)
+			}
+			if body["request_id"] != "req-123" || w.Header().Get("X-Request-ID") != "req-123" {
+				t.Errorf("request id: body %s, header %q, want req-123", w.Body, w.Header().Get("X-Request-ID"))
+			}
+			if detail, ok := body["detail"].(string); ok != tt.wantDetail || (ok && !strings.Contains(detail, "deliberate panic")) {
+				t.Errorf("body = %s, want detail with the panic %t", w.Body, tt.wantDetail)
+			}
+		})
+	}
+
+	// Production never registers /debug/panic, so check its envelope on a
+	// route that panics.
+	t.Run("production detail", func(t *testing.T) {
+		f := testFeatures(t, map[string]string{"APP_ENV": "production"})
+		r := gin.New()
+		r.Use(jsonRecovery(f.PanicDetail))
+		r.GET("/", handlePanic)
+		w := doRequest(r, http.MethodGet, "/", "")
+
+		var body map[string]any
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("decode %q: %v", w.Body, err)
+		}
+		if w.Code != http.StatusInternalServerError || body["request_id"] == nil {
+			t.Errorf("status %d, body %s, want a 500 with a request id", w.Code, w.Body)
+		}
+		if _, ok := body["detail"]; ok {
+			t.Errorf("body = %s, want no detail in production", w.Body)
+		}
+	})
+}
+
+func TestQueriesMaxCount(t *testing.T) {
+	tests := []struct {
+		env   map[string]string
+		query string
+		want  int
+	}{
+		{nil, "?count=30", 30},
+		{map[string]string{"MAX_QUERIES_COUNT": "20"}, "?count=30", 20},
+		{map[string]string{"MAX_QUERIES_COUNT": "20"}, "?count=0", 1},
+		{map[string]string{"MAX_QUERIES_COUNT": "20"}, "", 1},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.env, tt.query), func(t *testing.T) {
+			env := map[string]string{"MEMORY_SEED_SIZE": "50"}
+			for k, v := range tt.env {
+				env[k] = v
+			}
+			r, _ := newTestRouter(t, env)
+			w := doRequest(r, http.MethodGet, "/queries"+tt.query, "")
+
+			var users []User
+			if err := json.Unmarshal(w.Body.Bytes(), &users); err != nil {
+				t.Fatalf("decode %q: %v", w.Body, err)
+			}
+			if len(users) != tt.want {
+				t.Errorf("%d users, want %d", len(users), tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateUserReturnModes(t *testing.T) {
+	const body = `{"name":"New User","email":"new@example.com","age":30}`
+	tests := []struct {
+		name    string
+		path    string
+		header  []string
+		minimal bool
+	}{
+		{"default", "/users", nil, false},
+		{"return=representation", "/users", []string{"Prefer", "return=representation"}, false},
+		{"fields=id", "/users?fields=id", nil, true},
+		{"return=minimal", "/users", []string{"Prefer", "return=minimal"}, true},
+		{"return=minimal among preferences", "/users", []string{"Prefer", "respond-async, return=minimal"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := newTestRouter(t, nil)
+			w := doRequest(r, http.MethodPost, tt.path, body, tt.header...)
+			if w.Code != http.StatusCreated {
+				t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusCreated, w.Body)
+			}
+
+			var got map[string]any
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("decode %q: %v", w.Body, err)
+			}
+			if got["id"] != float64(11) {
+				t.Errorf("id = %v, want 11", got["id"])
+			}
+			if tt.minimal {
+				if len(got) != 1 {
+					t.Errorf("body = %s, want only the id", w.Body)
+				}
+				if loc := w.Header().Get("Location"); loc != "/users/11" {
+					t.Errorf("Location = %q, want /users/11", loc)
+				}
+				if pa := w.Header().Get("Preference-Applied"); pa != "return=minimal" {
+					t.Errorf("Preference-Applied = %q, want return=minimal", pa)
+				}
+			} else if got["email"] != "new@example.com" || got["name"] != "New User" {
+				t.Errorf("body = %s, want the full user", w.Body)
+			}
+		})
+	}
+}
+
+func TestStrictJSON(t *testing.T) {
+	const body = `{"name":"Typo","email":"typo@example.com","agee":5}`
+	tests := []struct {
+		strictJSON string
+		want       int
+	}{
+		{"", http.StatusCreated},
+		{"0", http.StatusCreated},
+		{"1", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run("STRICT_JSON="+tt.strictJSON, func(t *testing.T) {
+			r, repo := newTestRouter(t, map[string]string{"STRICT_JSON": tt.strictJSON})
+			w := doRequest(r, http.MethodPost, "/users", body)
+
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+			if tt.want == http.StatusBadRequest {
+				if !strings.Contains(w.Body.String(), "agee") {
+					t.Errorf("body %s does not name the unknown field", w.Body)
+				}
+				if len(repo.users) != 10 {
+					t.Errorf("%d users after a rejected create, want 10", len(repo.users))
+				}
+			}
+		})
+	}
+}
+
+func TestListUsersSortTieBreak(t *testing.T) {
+	// Ages are seeded as 18+i%62, so 70 users put two rows on eight ages.
+	r, _ := newTestRouter(t, map[string]string{"MEMORY_SEED_SIZE": "70"})
+
+	list := func(query string) []User {
+		t.Helper()
+		w := doRequest(r, http.MethodGet, "/users"+query, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET /users%s: status = %d: %s", query, w.Code, w.Body)
+		}
+		var users []User
+		if strings.Contains(query, "limit=") {
+			var page PaginatedUsers
+			if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+				t.Fatalf("decode %q: %v", w.Body, err)
+			}
+			users = page.Data
+		} else if err := json.Unmarshal(w.Body.Bytes(), &users); err != nil {
+			t.Fatalf("decode %q: %v", w.Body, err)
+		}
+		return users
+	}
+	ids := func(users []User) string {
+		s := make([]string, len(users))
+		for i, u := range users {
+			s[i] = fmt.Sprint(u.ID)
+		}
+		return strings.Join(s, ",")
+	}
+
+	for _, sort := range []string{"age", "-age"} {
+		t.Run(sort, func(t *testing.T) {
+			desc := sort == "-age"
+			first := list("?sort=" + sort)
+			if len(first) != 70 {
+				t.Fatalf("%d users, want 70", len(first))
+			}
+			for i := 1; i < len(first); i++ {
+				a, b := first[i-1], first[i]
+				if desc {
+					a, b = b, a
+				}
+				if *a.Age > *b.Age || (*a.Age == *b.Age && a.ID > b.ID) {
+					t.Fatalf("users %d and %d out of order: age %d id %d before age %d id %d",
+						i-1, i, *first[i-1].Age, first[i-1].ID, *first[i].Age, first[i].ID)
+				}
+			}
+			if second := list("?sort=" + sort); ids(second) != ids(first) {
+				t.Errorf("order changed between requests:\n%s\n%s", ids(first), ids(second))
+			}
+
+			// Pages split ties at every offset without repeating or losing a row.
+			var paged []User
+			for offset := 0; offset < 70; offset += 7 {
+				paged = append(paged, list(fmt.Sprintf("?sort=%s&limit=7&offset=%d", sort, offset))...)
+			}
+			if ids(paged) != ids(first) {
+				t.Errorf("pages differ from the full list:\n%s\n%s", ids(first), ids(paged))
+			}
+		})
+	}
+}
+
+func TestSetRetryAfter(t *testing.T) {
+	t.Cleanup(func() { retryAfterAsDate = false })
+
+	for _, tt := range []struct {
+		d    time.Duration
+		want string
+	}{
+		{time.Second, "1"},
+		{1500 * time.Millisecond, "2"},
+		{0, "1"},
+		{time.Minute, "60"},
+	} {
+		retryAfterAsDate = false
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		setRetryAfter(c, tt.d)
+		if got := w.Header()["Retry-After"]; len(got) != 1 || got[0] != tt.want {
+			t.Errorf("seconds, %s: Retry-After = %q, want [%q]", tt.d, got, tt.want)
+		}
+	}
+
+	retryAfterAsDate = true
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	before := time.Now().Truncate(time.Second)
+	setRetryAfter(c, time.Minute)
+
+	got := w.Header()["Retry-After"]
+	if len(got) != 1 {
+		t.Fatalf("date: Retry-After = %q, want one HTTP-date", got)
+	}
+	at, err := http.ParseTime(got[0])
+	if err != nil || !strings.HasSuffix(got[0], " GMT") {
+		t.Fatalf("date: Retry-After = %q is not an HTTP-date: %v", got[0], err)
+	}
+	if at.Before(before.Add(time.Minute)) || at.After(time.Now().Add(time.Minute)) {
+		t.Errorf("date: Retry-After = %q, want a minute from now", got[0])
+	}
+}
+
+func TestRetryAfterFormat(t *testing.T) {
+	t.Cleanup(func() { retryAfterAsDate = false })
+	exhausted := fmt.Errorf("%w: %w", ErrPoolExhausted, context.DeadlineExceeded)
+
+	for _, format := range []string{"seconds", "date"} {
+		t.Run(format, func(t *testing.T) {
+			f := testFeatures(t, map[string]string{"RETRY_AFTER_FORMAT": format})
+			r := testRouter(failingRepo{newMemoryUserRepository(f.MemorySeedSize), exhausted}, f)
+			w := doRequest(r, http.MethodGet, "/users/1", "")
+
+			if w.Code != http.StatusServiceUnavailable {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusServiceUnavailable)
+			}
+			got := w.Header().Get("Retry-After")
+			_, dateErr := http.ParseTime(got)
+			if isDate := dateErr == nil; isDate != (format == "date") {
+				t.Errorf("Retry-After = %q under RETRY_AFTER_FORMAT=%s", got, format)
+			}
+		})
+	}
+}
+
+func TestNullEmail(t *testing.T) {
+	t.Run("scan", func(t *testing.T) {
+		// pgx stores a NULL column into a **string as a nil pointer.
+		u, err := scanUser(func(dest ...any) error {
+			*dest[0].(*int) = 1
+			*dest[1].(*string) = "No Email"
+			*dest[2].(**string) = nil
+			return nil
+		})
+		if err != nil || u.Email != nil {
+			t.Fatalf("scanUser = %+v, %v, want a nil Email", u, err)
+		}
+	})
+
+	for _, tt := range []struct {
+		name string
+		env  map[string]string
+		path string
+		want string
+	}{
+		{"user", nil, "/users/1", `"email":null`},
+		{"user easyjson", map[string]string{"EASYJSON": "1"}, "/users/1", `"email":null`},
+		{"list", nil, "/users", `"email":null`},
+		{"list easyjson", map[string]string{"EASYJSON": "1"}, "/users", `"email":null`},
+		{"csv", nil, "/users.csv", "1,No Email,,"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			r, repo := newTestRouter(t, tt.env)
+			u := repo.users[1]
+			u.Name, u.Email = "No Email", nil
+			repo.users[1] = u
+
+			w := doRequest(r, http.MethodGet, tt.path, "")
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+			}
+			if !strings.Contains(w.Body.String(), tt.want) {
+				t.Errorf("body does not contain %s: %s", tt.want, w.Body)
+			}
+		})
+	}
+}
+
+func TestTrailingSlashRedirect(t *testing.T) {
+	tests := []struct {
+		redirect     string
+		method, path string
+		want         int
+		wantLocation string
+	}{
+		{"", http.MethodGet, "/users", http.StatusOK, ""},
+		{"", http.MethodGet, "/users/", http.StatusNotFound, ""},
+		{"", http.MethodGet, "/USERS", http.StatusNotFound, ""},
+		{"1", http.MethodGet, "/users", http.StatusOK, ""},
+		{"1", http.MethodGet, "/users/", http.StatusMovedPermanently, "/users"},
+		{"1", http.MethodPost, "/users/", http.StatusTemporaryRedirect, "/users"},
+		{"1", http.MethodGet, "/USERS", http.StatusMovedPermanently, "/users"},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("TRAILING_SLASH_REDIRECT=%s %s %s", tt.redirect, tt.method, tt.path), func(t *testing.T) {
+			r, _ := newTestRouter(t, map[string]string{"TRAILING_SLASH_REDIRECT": tt.redirect})
+			w := doRequest(r, tt.method, tt.path, "")
+
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d", w.Code, tt.want)
+			}
+			if got := w.Header().Get("Location"); got != tt.wantLocation {
+				t.Errorf("Location = %q, want %q", got, tt.wantLocation)
+			}
+		})
+	}
+}
+
+func TestNotFoundAndMethodNotAllowed(t *testing.T) {
+	tests := []struct {
+		method, path string
+		want         int
+		wantDetail   string
+		wantAllow    []string
+	}{
+		{http.MethodGet, "/nope", http.StatusNotFound, "Not found", nil},
+		{http.MethodPost, "/nope", http.StatusNotFound, "Not found", nil},
+		{http.MethodDelete, "/users", http.StatusMethodNotAllowed, "Method not allowed", []string{"GET", "POST", "PUT"}},
+		{http.MethodPost, "/json", http.StatusMethodNotAllowed, "Method not allowed", []string{"GET"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			r, _ := newTestRouter(t, nil)
+			w := doRequest(r, tt.method, tt.path, "")
+
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d", w.Code, tt.want)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != problem.ContentType {
+				t.Errorf("Content-Type = %q, want %q", ct, problem.ContentType)
+			}
+			var body struct {
+				Status int    `json:"status"`
+				Detail string `json:"detail"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode %q: %v", w.Body, err)
+			}
+			if body.Status != tt.want || body.Detail != tt.wantDetail {
+				t.Errorf("body = %s, want status %d and detail %q", w.Body, tt.want, tt.wantDetail)
+			}
+
+			allow := w.Header().Get("Allow")
+			if tt.wantAllow == nil && allow != "" {
+				t.Errorf("Allow = %q on a %d", allow, tt.want)
+			}
+			for _, m := range tt.wantAllow {
+				if !strings.Contains(allow, m) {
+					t.Errorf("Allow = %q, missing %s", allow, m)
+				}
+			}
+		})
+	}
+}
+
+// sequentialRepo hands out a new user on every Random call, so repeats in a
+// response can only come from coalescing.
+type sequentialRepo struct {
+	UserRepository
+	next atomic.Int64
+}
+
+func (r *sequentialRepo) Random(context.Context, RandomMode) (User, error) {
+	return User{ID: int(r.next.Add(1)), Name: "user"}, nil
+}
+
+func TestCoalescingOnlyWrapsDB(t *testing.T) {
+	f := testFeatures(t, map[string]string{"DB_COALESCE": "1", "DB_COALESCE_TTL": "1h"})
+	repo := &sequentialRepo{UserRepository: newMemoryUserRepository(f.MemorySeedSize)}
+	r := testRouter(repo, f)
+
+	var first, second User
+	for _, u := range []*User{&first, &second} {
+		w := doRequest(r, http.MethodGet, "/db", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("/db status = %d, want 200", w.Code)
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), u); err != nil {
+			t.Fatalf("decode %q: %v", w.Body, err)
+		}
+	}
+	if first.ID != second.ID {
+		t.Errorf("/db ids = %d, %d, want the cached user twice", first.ID, second.ID)
+	}
+
+	for _, workers := range []string{"0", "4"} {
+		t.Run("per-row workers="+workers, func(t *testing.T) {
+			t.Setenv("QUERY_WORKERS", workers)
+			f, err := loadFeatures()
+			if err != nil {
+				t.Fatalf("loadFeatures: %v", err)
+			}
+			w := doRequest(testRouter(repo, f), http.MethodGet, "/queries?conn=per-row&count=5", "")
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200", w.Code)
+			}
+			var users []User
+			if err := json.Unmarshal(w.Body.Bytes(), &users); err != nil {
+				t.Fatalf("decode %q: %v", w.Body, err)
+			}
+			seen := make(map[int]bool)
+			for _, u := range users {
+				if seen[u.ID] {
+					t.Fatalf("/queries?conn=per-row returned user %d twice: %s", u.ID, w.Body)
+				}
+				seen[u.ID] = true
+			}
+			if len(seen) != 5 {
+				t.Errorf("got %d users, want 5", len(seen))
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"log"
+	"net"
+	"net/http"
+
+	"github.com/quic-go/quic-go/http3"
+
+	"tlsconfig"
+)
+
+// ---------------------------------------------------------------------------
+// HTTP/3 listener (HTTP3_PORT)
+// ---------------------------------------------------------------------------
+
+// startHTTP3 serves the router of srv over HTTP/3 (QUIC) on UDP addr,
+// alongside the HTTP/1.1 listener and with the same idle timeout and header
+// limit, so a run can compare the protocols against one process. QUIC always
+// runs over TLS 1.3, hence the certificate, reloaded on SIGHUP like
+// TLS_CERT. No Alt-Svc header is added to HTTP/1.1 responses: the load
+// generator picks the protocol explicitly rather than upgrading mid-run.
+//
+// The UDP socket is bound before returning, so a port in use fails startup
+// and Shutdown cannot race the bind.
+func startHTTP3(addr string, srv *http.Server, certFile, keyFile string) (*http3.Server, error) {
+	tlsConfig, err := tlsconfig.Load(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	h3 := &http3.Server{
+		Handler:        srv.Handler,
+		TLSConfig:      http3.ConfigureTLSConfig(tlsConfig),
+		IdleTimeout:    srv.IdleTimeout,
+		MaxHeaderBytes: srv.MaxHeaderBytes,
+	}
+	go func() {
+		if err := h3.Serve(conn); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("http3 server error: %v", err)
+		}
+	}()
+	return h3, nil
+}
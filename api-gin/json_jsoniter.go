@@ -0,0 +1,7 @@
+//go:build jsoniter
+
+package main
+
+// jsonEngine names the encoder behind gin's c.JSON, here selected by gin's
+// own jsoniter build tag.
+const jsonEngine = "jsoniter"
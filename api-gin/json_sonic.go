@@ -0,0 +1,7 @@
+//go:build sonic && avx && (linux || windows || darwin) && amd64
+
+package main
+
+// jsonEngine names the encoder behind gin's c.JSON. The constraint mirrors
+// gin's internal/json/sonic.go: outside it gin silently keeps encoding/json.
+const jsonEngine = "sonic"
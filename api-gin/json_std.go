@@ -0,0 +1,6 @@
+//go:build !jsoniter && !(sonic && avx && (linux || windows || darwin) && amd64)
+
+package main
+
+// jsonEngine names the encoder behind gin's c.JSON.
+const jsonEngine = "encoding/json"
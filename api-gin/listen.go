@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// ---------------------------------------------------------------------------
+// Public listeners (LISTEN, REUSEPORT)
+// ---------------------------------------------------------------------------
+
+// listenPublic opens the public API socket(s). An addr of the form
+// "unix:<path>" (LISTEN=unix:/tmp/api.sock) is a Unix domain socket, which
+// takes the kernel TCP stack out of the measurement when the load generator
+// runs on the same host; n is ignored for it. With n > 0 (REUSEPORT=1) it
+// binds n sockets to addr with SO_REUSEPORT: the kernel spreads incoming
+// connections across them, each with its own accept queue and accept loop,
+// instead of every connection going through a single queue. Otherwise it is
+// a plain single listener.
+func listenPublic(addr string, n int) ([]net.Listener, error) {
+	if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+		l, err := listenUnix(path)
+		if err != nil {
+			return nil, err
+		}
+		return []net.Listener{l}, nil
+	}
+	if n <= 0 {
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			return nil, err
+		}
+		return []net.Listener{l}, nil
+	}
+
+	lc := net.ListenConfig{Control: reusePortControl}
+	listeners := make([]net.Listener, 0, n)
+	for i := 0; i < n; i++ {
+		l, err := lc.Listen(context.Background(), "tcp", addr)
+		if err != nil {
+			for _, open := range listeners {
+				open.Close()
+			}
+			return nil, err
+		}
+		listeners = append(listeners, l)
+	}
+	return listeners, nil
+}
+
+// listenUnix binds a Unix domain socket at path, removing a stale socket
+// left there by a previous run. The socket is made world-writable so a load
+// generator running as another user (or in another container sharing the
+// directory) can connect; it is unlinked again when the listener closes.
+func listenUnix(path string) (net.Listener, error) {
+	if fi, err := os.Lstat(path); err == nil {
+		if fi.Mode().Type() != fs.ModeSocket {
+			return nil, errors.New(path + " exists and is not a socket")
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0o666); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// serve serves srv on l, over TLS when srv has a TLSConfig.
+func serve(srv *http.Server, l net.Listener) error {
+	if srv.TLSConfig != nil {
+		return srv.ServeTLS(l, "", "")
+	}
+	return srv.Serve(l)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestParseCount(t *testing.T) {
+	tests := []struct {
+		raw  string
+		max  int
+		want int
+	}{
+		{"", defaultMaxQueriesCount, 1},
+		{"abc", defaultMaxQueriesCount, 1},
+		{"0", defaultMaxQueriesCount, 1},
+		{"-5", defaultMaxQueriesCount, 1},
+		{"20", defaultMaxQueriesCount, 20},
+		{"501", defaultMaxQueriesCount, 500},
+		{"20", 10, 10},
+		{"10", 10, 10},
+		{"600", 1000, 600},
+		{"5000", 1000, 1000},
+		{"0", 10, 1},
+	}
+	for _, tt := range tests {
+		if got := parseCount(tt.raw, tt.max); got != tt.want {
+			t.Errorf("parseCount(%q, %d) = %d, want %d", tt.raw, tt.max, got, tt.want)
+		}
+	}
+}
+
+func TestBuildDSN(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{"nothing set", nil, defaultDSN},
+		{"url set", map[string]string{"DATABASE_URL": "postgres://u:p@db:5433/app"}, "postgres://u:p@db:5433/app"},
+		{"url wins over components", map[string]string{"DATABASE_URL": "postgres://u:p@db/app", "PGHOST": "other"}, "postgres://u:p@db/app"},
+		{"all components", map[string]string{
+			"PGHOST": "db", "PGPORT": "5433", "PGUSER": "app", "PGPASSWORD": "pw", "PGDATABASE": "appdb", "PGSSLMODE": "require",
+		}, "host='db' port='5433' user='app' password='pw' dbname='appdb' sslmode='require'"},
+		{"some components", map[string]string{"PGHOST": "db"},
+			"host='db' port='5432' user='benchmark' password='benchmark' dbname='benchmark'"},
+		{"password escaping", map[string]string{"PGPASSWORD": `it's a \ p=w`},
+			`host='localhost' port='5432' user='benchmark' password='it\'s a \\ p=w' dbname='benchmark'`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildDSN(func(k string) string { return tt.env[k] })
+			if got != tt.want {
+				t.Fatalf("buildDSN = %s, want %s", got, tt.want)
+			}
+			cfg, err := pgconn.ParseConfig(got)
+			if err != nil {
+				t.Fatalf("pgconn.ParseConfig(%s): %v", got, err)
+			}
+			if pw, ok := tt.env["PGPASSWORD"]; ok && cfg.Password != pw {
+				t.Errorf("password = %q, want %q", cfg.Password, pw)
+			}
+		})
+	}
+}
@@ -0,0 +1,423 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// In-memory data source (DATA_SOURCE=memory)
+// ---------------------------------------------------------------------------
+
+// memoryUserRepository implements UserRepository on a map guarded by a
+// RWMutex, so the benchmark can measure routing and serialization with no
+// database latency. ids mirrors the map keys for O(1) random picks; pos maps
+// an id to its index in ids so deletes can swap-remove.
+type memoryUserRepository struct {
+	mu     sync.RWMutex
+	users  map[int]User
+	ids    []int
+	pos    map[int]int
+	emails map[string]int // email -> id, for the unique constraint
+
+	nextID atomic.Int64
+}
+
+// Seed data mirrors scripts/init.sql so both data sources serve the same shape.
+var (
+	seedFirstNames = []string{
+		"Alice", "Bob", "Carlos", "Diana", "Eduardo", "Fernanda", "Gabriel", "Helena",
+		"Igor", "Julia", "Kevin", "Laura", "Marcos", "Natalia", "Otto", "Paula",
+		"Rafael", "Sofia", "Thiago", "Ursula", "Victor", "Wendy", "Xander", "Yasmin", "Zeca",
+	}
+	seedLastNames = []string{
+		"Silva", "Santos", "Oliveira", "Souza", "Costa", "Ferreira", "Alves", "Pereira",
+		"Lima", "Carvalho", "Melo", "Ribeiro", "Almeida", "Nascimento", "Gomes",
+	}
+	seedDomains = []string{"gmail.com", "outlook.com", "yahoo.com", "hotmail.com", "benchmark.dev"}
+)
+
+// newMemoryUserRepository returns a store pre-seeded with size users.
+func newMemoryUserRepository(size int) *memoryUserRepository {
+	r := &memoryUserRepository{
+		users:  make(map[int]User, size),
+		ids:    make([]int, 0, size),
+		pos:    make(map[int]int, size),
+		emails: make(map[string]int, size),
+	}
+	now := time.Now().UTC()
+	for i := 1; i <= size; i++ {
+		email := fmt.Sprintf("user%d@%s", i, seedDomains[i%len(seedDomains)])
+		age := 18 + i%62
+		r.insert(User{
+			ID:        i,
+			Name:      seedFirstNames[i%len(seedFirstNames)] + " " + seedLastNames[i%len(seedLastNames)],
+			Email:     &email,
+			Age:       &age,
+			CreatedAt: now,
+			Version:   1,
+		})
+	}
+	r.nextID.Store(int64(size))
+	return r
+}
+
+// insert adds u to every index. The caller holds mu (or owns r exclusively).
+func (r *memoryUserRepository) insert(u User) {
+	r.users[u.ID] = u
+	r.pos[u.ID] = len(r.ids)
+	r.ids = append(r.ids, u.ID)
+	if u.Email != nil {
+		r.emails[*u.Email] = u.ID
+	}
+}
+
+// emailTaken reports whether email belongs to a user other than id. The
+// caller holds mu.
+func (r *memoryUserRepository) emailTaken(email *string, id int) bool {
+	if email == nil {
+		return false
+	}
+	owner, ok := r.emails[*email]
+	return ok && owner != id
+}
+
+// setEmail moves the unique-email index entry of u to email. The caller
+// holds mu for writing.
+func (r *memoryUserRepository) setEmail(u *User, email *string) {
+	if u.Email != nil {
+		delete(r.emails, *u.Email)
+	}
+	if email != nil {
+		r.emails[*email] = u.ID
+	}
+	u.Email = email
+}
+
+// Random ignores mode: picking a random index is already what the id
+// lookup approximates.
+func (r *memoryUserRepository) Random(ctx context.Context, mode RandomMode) (User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.ids) == 0 {
+		return User{}, ErrNotFound
+	}
+	return r.users[r.ids[rand.IntN(len(r.ids))]], nil
+}
+
+// RandomSampled has no block sampling to emulate; it is Random.
+func (r *memoryUserRepository) RandomSampled(ctx context.Context) (User, error) {
+	return r.Random(ctx, RandomByID)
+}
+
+// RandomN picks min(n, Count) distinct users with Floyd's algorithm, like
+// ORDER BY RANDOM() LIMIT n never repeats a row.
+func (r *memoryUserRepository) RandomN(ctx context.Context, n int, mode RandomMode) ([]User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	total := len(r.ids)
+	n = min(n, total)
+	users := getUsers(n)
+	picked := make(map[int]bool, n)
+	for j := total - n; j < total; j++ {
+		t := rand.IntN(j + 1)
+		if picked[t] {
+			t = j
+		}
+		picked[t] = true
+		users = append(users, r.users[r.ids[t]])
+	}
+	return users, nil
+}
+
+// RandomPinned has no connections to pin; it runs n Random picks.
+func (r *memoryUserRepository) RandomPinned(ctx context.Context, n int, mode RandomMode) ([]User, error) {
+	users := getUsers(n)
+	for i := 0; i < n; i++ {
+		user, err := r.Random(ctx, mode)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+// RandomBatched has no round trips to save; it runs n Random picks.
+func (r *memoryUserRepository) RandomBatched(ctx context.Context, n int, mode RandomMode) ([]User, error) {
+	return r.RandomPinned(ctx, n, mode)
+}
+
+func (r *memoryUserRepository) List(ctx context.Context, order UserOrder, limit, offset int) ([]User, error) {
+	return r.sorted(order, limit, offset), nil
+}
+
+func (r *memoryUserRepository) Stream(ctx context.Context, order UserOrder, limit, offset int, fn func(User) error) error {
+	// fn writes to the client, so it runs on a snapshot, outside the lock.
+	for _, user := range r.sorted(order, limit, offset) {
+		if err := fn(user); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sorted returns a page of users in order; limit 0 means no limit.
+func (r *memoryUserRepository) sorted(order UserOrder, limit, offset int) []User {
+	r.mu.RLock()
+	users := make([]User, 0, len(r.users))
+	for _, u := range r.users {
+		users = append(users, u)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(users, func(i, j int) bool {
+		c := compareUsers(users[i], users[j], order.Column)
+		if order.Desc {
+			return c > 0
+		}
+		return c < 0
+	})
+
+	if offset >= len(users) {
+		return []User{}
+	}
+	users = users[offset:]
+	if limit > 0 && limit < len(users) {
+		users = users[:limit]
+	}
+	return users
+}
+
+// compareUsers orders a and b by column with the id tie-break, sorting nulls
+// after values as PostgreSQL does in ascending order (and, since the caller
+// negates the result for DESC, before them in descending order).
+func compareUsers(a, b User, column string) int {
+	var c int
+	switch column {
+	case "name":
+		c = strings.Compare(a.Name, b.Name)
+	case "email":
+		c = compareNullable(a.Email, b.Email, strings.Compare)
+	case "age":
+		c = compareNullable(a.Age, b.Age, func(x, y int) int { return x - y })
+	case "created_at":
+		c = a.CreatedAt.Compare(b.CreatedAt)
+	}
+	if c != 0 {
+		return c
+	}
+	return a.ID - b.ID
+}
+
+func compareNullable[T any](a, b *T, cmp func(T, T) int) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	}
+	return cmp(*a, *b)
+}
+
+func (r *memoryUserRepository) Count(ctx context.Context) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.users), nil
+}
+
+func (r *memoryUserRepository) Stats(ctx context.Context) (UserStats, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	st := UserStats{Count: len(r.users)}
+	var sum, n int
+	for _, u := range r.users {
+		if u.Age == nil {
+			st.NullAgeCount++
+			continue
+		}
+		age := *u.Age
+		if st.MinAge == nil || age < *st.MinAge {
+			st.MinAge = &age
+		}
+		if st.MaxAge == nil || age > *st.MaxAge {
+			st.MaxAge = &age
+		}
+		sum += age
+		n++
+	}
+	if n > 0 {
+		avg := float64(sum) / float64(n)
+		st.AvgAge = &avg
+	}
+	return st, nil
+}
+
+func (r *memoryUserRepository) GetByID(ctx context.Context, id int) (User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	user, ok := r.users[id]
+	if !ok {
+		return User{}, ErrNotFound
+	}
+	return user, nil
+}
+
+func (r *memoryUserRepository) Create(ctx context.Context, req CreateUserRequest) (User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	email := req.Email
+	if r.emailTaken(&email, 0) {
+		return User{}, ErrEmailTaken
+	}
+	user := User{
+		ID:        int(r.nextID.Add(1)),
+		Name:      req.Name,
+		Email:     &email,
+		Age:       req.Age,
+		CreatedAt: time.Now().UTC(),
+		Version:   1,
+	}
+	r.insert(user)
+	return user, nil
+}
+
+func (r *memoryUserRepository) CreateMinimal(ctx context.Context, req CreateUserRequest) (int, error) {
+	user, err := r.Create(ctx, req)
+	return user.ID, err
+}
+
+func (r *memoryUserRepository) Update(ctx context.Context, id int, req UpdateUserRequest, version int) (User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return User{}, ErrNotFound
+	}
+	if version != 0 && user.Version != version {
+		return User{}, ErrVersionMismatch
+	}
+	if r.emailTaken(req.Email, id) {
+		return User{}, ErrEmailTaken
+	}
+	return r.apply(user, req), nil
+}
+
+// apply stores user with the non-nil fields of req and a bumped version.
+// The caller holds mu for writing and has checked the email is free.
+func (r *memoryUserRepository) apply(user User, req UpdateUserRequest) User {
+	if req.Name != nil {
+		user.Name = *req.Name
+	}
+	if req.Email != nil {
+		email := *req.Email
+		r.setEmail(&user, &email)
+	}
+	if req.Age != nil {
+		age := *req.Age
+		user.Age = &age
+	}
+	user.Version++
+	r.users[user.ID] = user
+	return user
+}
+
+// UpdateMany applies the batch under one write lock, remembering each
+// touched row's original so a failure can restore all of them.
+func (r *memoryUserRepository) UpdateMany(ctx context.Context, reqs []BulkUpdateUserRequest) ([]User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	orig := make(map[int]User, len(reqs))
+	users := make([]User, 0, len(reqs))
+	for _, req := range reqs {
+		user, ok := r.users[req.ID]
+		if !ok {
+			r.restore(orig)
+			return nil, &BulkUpdateError{ID: req.ID, Err: ErrNotFound}
+		}
+		if r.emailTaken(req.Email, req.ID) {
+			r.restore(orig)
+			return nil, &BulkUpdateError{ID: req.ID, Err: ErrEmailTaken}
+		}
+		if _, seen := orig[req.ID]; !seen {
+			orig[req.ID] = user
+		}
+		users = append(users, r.apply(user, req.UpdateUserRequest))
+	}
+	return users, nil
+}
+
+// restore puts back the original rows of a failed batch. Emails are
+// unindexed first and reindexed after, since rows in the batch may have
+// swapped them. The caller holds mu for writing.
+func (r *memoryUserRepository) restore(orig map[int]User) {
+	for id := range orig {
+		if cur := r.users[id]; cur.Email != nil {
+			delete(r.emails, *cur.Email)
+		}
+	}
+	for id, user := range orig {
+		r.users[id] = user
+		if user.Email != nil {
+			r.emails[*user.Email] = id
+		}
+	}
+}
+
+func (r *memoryUserRepository) Replace(ctx context.Context, u User) (User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[u.ID]
+	if !ok {
+		return User{}, ErrNotFound
+	}
+	if user.Version != u.Version {
+		return User{}, ErrVersionMismatch
+	}
+	if r.emailTaken(u.Email, u.ID) {
+		return User{}, ErrEmailTaken
+	}
+
+	user.Name = u.Name
+	r.setEmail(&user, u.Email)
+	user.Age = u.Age
+	user.Version++
+	r.users[u.ID] = user
+	return user, nil
+}
+
+func (r *memoryUserRepository) Delete(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if user.Email != nil {
+		delete(r.emails, *user.Email)
+	}
+	delete(r.users, id)
+
+	i := r.pos[id]
+	last := r.ids[len(r.ids)-1]
+	r.ids[i] = last
+	r.pos[last] = i
+	r.ids = r.ids[:len(r.ids)-1]
+	delete(r.pos, id)
+	return nil
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ---------------------------------------------------------------------------
+// Prometheus metrics (METRICS_ENABLED=1)
+// ---------------------------------------------------------------------------
+
+// Connection-pool metrics, refreshed by runPoolStatsFlusher from db.Stat().
+// Gauges for the current state of the pool:
+//
+//	gin_db_pool_max_connections               pool size (MaxConns)
+//	gin_db_pool_open_connections              established connections (in use + idle)
+//	gin_db_pool_idle_connections              idle connections
+//	gin_db_pool_in_use_connections            connections currently in use
+//	gin_db_pool_constructing_connections      connections being established
+var (
+	poolMaxConnections = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "gin_db_pool_max_connections",
+		Help: "Maximum size of the pool.",
+	})
+	poolOpenConnections = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "gin_db_pool_open_connections",
+		Help: "Number of established connections, both in use and idle.",
+	})
+	poolIdleConnections = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "gin_db_pool_idle_connections",
+		Help: "Number of idle connections.",
+	})
+	poolInUseConnections = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "gin_db_pool_in_use_connections",
+		Help: "Number of connections currently in use.",
+	})
+	poolConstructingConnections = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "gin_db_pool_constructing_connections",
+		Help: "Number of connections being established.",
+	})
+)
+
+// Counters for the pool's cumulative totals. pgxpool only hands out the
+// totals, never increments, so poolCounters reports the latest snapshot as
+// CounterValue instead of adding to prometheus.Counters; rate() and
+// increase() then work on them as on any counter.
+//
+//	gin_db_pool_acquires_total                    successful acquires
+//	gin_db_pool_acquire_duration_seconds_total    time spent acquiring
+//	gin_db_pool_waits_total                       acquires that waited for a connection
+//	gin_db_pool_canceled_acquires_total           acquires cancelled by their context
+//	gin_db_pool_new_connections_total             connections opened
+//	gin_db_pool_idle_destroys_total               connections closed by MaxConnIdleTime
+var (
+	poolAcquiresDesc = prometheus.NewDesc("gin_db_pool_acquires_total",
+		"Total number of successful connection acquires.", nil, nil)
+	poolAcquireDurationDesc = prometheus.NewDesc("gin_db_pool_acquire_duration_seconds_total",
+		"Total time spent in successful connection acquires.", nil, nil)
+	poolWaitsDesc = prometheus.NewDesc("gin_db_pool_waits_total",
+		"Total number of acquires that waited for a connection because none was idle.", nil, nil)
+	poolCanceledAcquiresDesc = prometheus.NewDesc("gin_db_pool_canceled_acquires_total",
+		"Total number of acquires cancelled by their context.", nil, nil)
+	poolNewConnectionsDesc = prometheus.NewDesc("gin_db_pool_new_connections_total",
+		"Total number of connections opened.", nil, nil)
+	poolIdleDestroysDesc = prometheus.NewDesc("gin_db_pool_idle_destroys_total",
+		"Total number of connections closed for exceeding MaxConnIdleTime.", nil, nil)
+)
+
+// poolCounterCollector is the prometheus.Collector behind the pool
+// counters. It exports nothing until recordPoolStats has stored a snapshot.
+type poolCounterCollector struct {
+	last atomic.Pointer[pgxpool.Stat]
+}
+
+var poolCounters = &poolCounterCollector{}
+
+func init() {
+	prometheus.MustRegister(poolCounters)
+}
+
+func (c *poolCounterCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- poolAcquiresDesc
+	ch <- poolAcquireDurationDesc
+	ch <- poolWaitsDesc
+	ch <- poolCanceledAcquiresDesc
+	ch <- poolNewConnectionsDesc
+	ch <- poolIdleDestroysDesc
+}
+
+func (c *poolCounterCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.last.Load()
+	if s == nil {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(poolAcquiresDesc, prometheus.CounterValue, float64(s.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolAcquireDurationDesc, prometheus.CounterValue, s.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(poolWaitsDesc, prometheus.CounterValue, float64(s.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolCanceledAcquiresDesc, prometheus.CounterValue, float64(s.CanceledAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolNewConnectionsDesc, prometheus.CounterValue, float64(s.NewConnsCount()))
+	ch <- prometheus.MustNewConstMetric(poolIdleDestroysDesc, prometheus.CounterValue, float64(s.MaxIdleDestroyCount()))
+}
+
+// requestDuration is observed by requestMetrics for every request. Route is
+// the registered pattern (/users/:id), keeping label cardinality bounded.
+var requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "gin_http_request_duration_seconds",
+	Help:    "Request latency by method, route and status.",
+	Buckets: prometheus.ExponentialBuckets(0.0005, 2, 14), // 0.5ms .. ~4s
+}, []string{"method", "route", "status"})
+
+// requestMetrics records requestDuration. With exemplars set (metrics and
+// TRACING_ENABLED both on) each observation made under a trace carries its
+// trace_id and span_id, so a latency spike on a dashboard links to a
+// representative trace. Exemplars are only exposed to OpenMetrics scrapes.
+func requestMetrics(exemplars bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		obs := requestDuration.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status()))
+		elapsed := time.Since(start).Seconds()
+
+		if exemplars {
+			if sc := trace.SpanContextFromContext(c.Request.Context()); sc.IsValid() {
+				obs.(prometheus.ExemplarObserver).ObserveWithExemplar(elapsed, prometheus.Labels{
+					"trace_id": sc.TraceID().String(),
+					"span_id":  sc.SpanID().String(),
+				})
+				return
+			}
+		}
+		obs.Observe(elapsed)
+	}
+}
+
+// recordPoolStats copies a db.Stat() snapshot into the pool gauges and
+// hands it to poolCounters.
+func recordPoolStats(s *pgxpool.Stat) {
+	poolMaxConnections.Set(float64(s.MaxConns()))
+	poolOpenConnections.Set(float64(s.TotalConns()))
+	poolIdleConnections.Set(float64(s.IdleConns()))
+	poolInUseConnections.Set(float64(s.AcquiredConns()))
+	poolConstructingConnections.Set(float64(s.ConstructingConns()))
+	poolCounters.last.Store(s)
+}
+
+// runPoolStatsFlusher refreshes the pool metrics every interval until ctx is
+// cancelled, giving continuous pool visibility without polling an endpoint.
+func runPoolStatsFlusher(ctx context.Context, db *pgxpool.Pool, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	recordPoolStats(db.Stat())
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("pool stats flusher stopped")
+			return
+		case <-ticker.C:
+			recordPoolStats(db.Stat())
+		}
+	}
+}
@@ -0,0 +1,356 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	mathrand "math/rand"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// ---------------------------------------------------------------------------
+// Middleware
+// ---------------------------------------------------------------------------
+
+// headerRequestID is X-Request-ID in canonical form, for direct header map
+// access.
+const headerRequestID = "X-Request-Id"
+
+// maxRequestIDLen bounds a caller-supplied X-Request-ID; longer ones are
+// replaced rather than echoed and logged.
+const maxRequestIDLen = 128
+
+// Fresh request IDs are "<prefix>-<seq>": a random per-process prefix, so
+// restarts and prefork children do not collide, and a hex counter. Minting
+// one costs a single string allocation instead of a crypto/rand read.
+var (
+	requestIDPrefix = newRequestIDPrefix()
+	requestIDSeq    atomic.Uint64
+)
+
+func newRequestIDPrefix() string {
+	var b [4]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return strconv.FormatInt(int64(os.Getpid()), 16)
+	}
+	return hex.EncodeToString(b[:])
+}
+
+func newRequestID() string {
+	var buf [32]byte
+	b := append(buf[:0], requestIDPrefix...)
+	b = append(b, '-')
+	b = strconv.AppendUint(b, requestIDSeq.Add(1), 16)
+	return string(b)
+}
+
+// validRequestID accepts up to maxRequestIDLen visible ASCII characters, so
+// a caller's ID cannot inject header or log lines.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// requestIDs puts an X-Request-ID on every response, errors included, so a
+// load generator's failures can be matched with server-side logs. A caller
+// that sends a valid one gets it back, letting the load generator choose
+// its own IDs; otherwise a fresh one is minted. The request's header slice
+// is reused as is, so echoing allocates nothing.
+func requestIDs() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		h := c.Writer.Header()
+		if v := c.Request.Header[headerRequestID]; len(v) == 1 && validRequestID(v[0]) {
+			h[headerRequestID] = v
+		} else {
+			h[headerRequestID] = []string{newRequestID()}
+		}
+		c.Next()
+	}
+}
+
+// requestID returns the X-Request-ID of the response, minting one when
+// requestIDs is not installed on the engine.
+func requestID(c *gin.Context) string {
+	h := c.Writer.Header()
+	if v := h[headerRequestID]; len(v) > 0 {
+		return v[0]
+	}
+	id := newRequestID()
+	h[headerRequestID] = []string{id}
+	return id
+}
+
+// responseTimeWriter stamps X-Response-Time onto the headers at the moment
+// they are committed, which is the last chance to add one.
+type responseTimeWriter struct {
+	gin.ResponseWriter
+	start   time.Time
+	stamped bool
+}
+
+func (w *responseTimeWriter) stamp() {
+	if w.stamped || w.ResponseWriter.Written() {
+		return
+	}
+	w.stamped = true
+	ms := float64(time.Since(w.start).Microseconds()) / 1000
+	w.Header().Set("X-Response-Time", strconv.FormatFloat(ms, 'f', 3, 64)+"ms")
+}
+
+func (w *responseTimeWriter) WriteHeaderNow() {
+	w.stamp()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *responseTimeWriter) Write(b []byte) (int, error) {
+	w.stamp()
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseTimeWriter) WriteString(s string) (int, error) {
+	w.stamp()
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *responseTimeWriter) Flush() {
+	w.stamp()
+	w.ResponseWriter.Flush()
+}
+
+// responseTime sets X-Response-Time: <ms>ms on every response, errors
+// included, so client tooling can cross-check its own latency numbers. The
+// value is the time until the headers were sent; bodiless responses (204)
+// are stamped after the handler returns, before Gin flushes the status.
+func responseTime() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		w := &responseTimeWriter{ResponseWriter: c.Writer, start: time.Now()}
+		c.Writer = w
+		c.Next()
+		w.stamp()
+	}
+}
+
+// traceContext joins the caller's trace: the W3C traceparent/tracestate
+// headers are extracted onto the request context as the active span
+// context, where requestMetrics picks it up for exemplars. No tracing SDK is
+// linked in, so nothing is exported from here. Installed only when
+// TRACING_ENABLED=1.
+func traceContext() gin.HandlerFunc {
+	var propagator propagation.TraceContext
+	return func(c *gin.Context) {
+		ctx := propagator.Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// jsonRecovery replaces gin.Recovery(), which answers panics with an empty
+// 500 body. The panic and its stack are logged through slog and the client
+// gets the standard problem details envelope. The body only has a "detail",
+// carrying the panic value, when exposeDetail is set, i.e. APP_ENV names a
+// development environment (see devEnv).
+func jsonRecovery(exposeDetail bool) gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, err any) {
+		id := requestID(c)
+		slog.Error("panic recovered",
+			"request_id", id,
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"panic", fmt.Sprint(err),
+			"stack", string(debug.Stack()),
+		)
+
+		details := newProblem(c, http.StatusInternalServerError, "", "")
+		if exposeDetail {
+			details = newProblem(c, http.StatusInternalServerError, "Internal server error", fmt.Sprint(err))
+		}
+		c.Abort()
+		problemJSON(c, http.StatusInternalServerError, PanicResponse{
+			Details:   details,
+			RequestID: id,
+		})
+	})
+}
+
+// GET /debug/panic — deliberately panics; only registered when APP_ENV=test.
+func handlePanic(c *gin.Context) {
+	panic("deliberate panic from /debug/panic")
+}
+
+// strictQueryParams rejects requests that repeat a query parameter
+// (e.g. ?count=5&count=500). Every parameter this API reads is
+// single-valued, and c.Query silently keeps the first occurrence, which can
+// mask bugs in benchmark scripts. Installed only when STRICT_QUERY_PARAMS=1.
+func strictQueryParams() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.URL.RawQuery == "" {
+			c.Next()
+			return
+		}
+		for key, values := range c.Request.URL.Query() {
+			if len(values) > 1 {
+				c.Abort()
+				errorJSON(c, http.StatusBadRequest, "Duplicate query parameter: "+key, "")
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// backpressure sheds load before requests queue on the connection pool: at
+// most limit requests may be in flight on the routes it guards, and the
+// excess get 429 immediately with a Retry-After hint instead of waiting for
+// a connection while latency balloons. Enabled by ENABLE_BACKPRESSURE=1.
+func backpressure(limit int) gin.HandlerFunc {
+	return limitInFlight(limit, http.StatusTooManyRequests)
+}
+
+// concurrencyLimit caps the requests in flight on the whole public engine
+// (MAX_CONCURRENCY) and answers the excess with 503 and Retry-After, so an
+// overload run measures graceful shedding rather than every request's
+// latency growing together. Unlike backpressure it also covers the routes
+// that never touch the database.
+func concurrencyLimit(limit int) gin.HandlerFunc {
+	return limitInFlight(limit, http.StatusServiceUnavailable)
+}
+
+// limitInFlight admits at most limit concurrent requests through a buffered
+// channel used as a semaphore and rejects the rest with status without
+// queueing them.
+func limitInFlight(limit, status int) gin.HandlerFunc {
+	sem := make(chan struct{}, limit)
+
+	return func(c *gin.Context) {
+		select {
+		case sem <- struct{}{}:
+		default:
+			setRetryAfter(c, retryAfterDelay)
+			c.Abort()
+			problemJSON(c, status, RetryErrorResponse{
+				Details:    newProblem(c, status, "Server overloaded", ""),
+				RetryAfter: retryAfterSeconds,
+			})
+			return
+		}
+		defer func() { <-sem }()
+		c.Next()
+	}
+}
+
+// countingReader counts the bytes read through it, for request bodies whose
+// size is not announced by Content-Length (chunked transfer encoding).
+type countingReader struct {
+	io.ReadCloser
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// accessLog emits one structured slog line per request with timing and
+// payload sizes. The request size comes from Content-Length when present;
+// otherwise the body is wrapped in a countingReader, which is transparent to
+// binding because handlers still read c.Request.Body. Installed only when
+// ACCESS_LOG=1, since logging every request costs benchmark throughput.
+func accessLog(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		var counter *countingReader
+		if c.Request.ContentLength < 0 && c.Request.Body != nil && c.Request.Body != http.NoBody {
+			counter = &countingReader{ReadCloser: c.Request.Body}
+			c.Request.Body = counter
+		}
+
+		c.Next()
+
+		reqBytes := c.Request.ContentLength
+		if counter != nil {
+			reqBytes = counter.n
+		}
+		respBytes := c.Writer.Size()
+		if respBytes < 0 {
+			respBytes = 0
+		}
+
+		logger.LogAttrs(c.Request.Context(), slog.LevelInfo, "request",
+			slog.String("request_id", requestID(c)),
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", c.Writer.Status()),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.Int64("req_bytes", max(reqBytes, 0)),
+			slog.Int("resp_bytes", respBytes),
+		)
+	}
+}
+
+// faultExemptPaths are never subject to fault injection so orchestration
+// (health checks, readiness, scraping) keeps working during resilience runs.
+var faultExemptPaths = map[string]bool{
+	"/":               true,
+	"/healthz":        true,
+	"/readyz":         true,
+	"/readyz/replica": true,
+	"/metrics":        true,
+	"/features":       true,
+}
+
+// faultInjection delays a fraction (delayRate) of requests by delay and fails
+// a fraction (errorRate) with 500, for benchmarking client-side timeout and
+// retry behaviour. Decisions come from a PRNG seeded with seed, so a run
+// with the same request sequence is reproducible. Installed only when
+// FAULT_RATE or FAULT_ERROR_RATE is set.
+func faultInjection(delay time.Duration, delayRate, errorRate float64, seed int64) gin.HandlerFunc {
+	var mu sync.Mutex
+	rng := mathrand.New(mathrand.NewSource(seed))
+
+	return func(c *gin.Context) {
+		if faultExemptPaths[c.FullPath()] {
+			c.Next()
+			return
+		}
+
+		mu.Lock()
+		injectDelay := delay > 0 && rng.Float64() < delayRate
+		injectError := rng.Float64() < errorRate
+		mu.Unlock()
+
+		if injectDelay {
+			select {
+			case <-time.After(delay):
+			case <-c.Request.Context().Done():
+				c.Abort()
+				return
+			}
+		}
+		if injectError {
+			c.Abort()
+			errorJSON(c, http.StatusInternalServerError, "Injected fault", "")
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"runtime/pprof"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ---------------------------------------------------------------------------
+// Profile-guided optimization
+// ---------------------------------------------------------------------------
+
+const (
+	defaultPGOSeconds = 30
+	maxPGOSeconds     = 300
+)
+
+// POST /debug/pgo?seconds=30 — records a CPU profile for the given window
+// and writes it to PGO_PROFILE_PATH (default.pgo). Only registered with
+// PGO_PROFILE=1. The request blocks until the file is in place, so it is
+// meant to be fired while the load generator is running; the profile then
+// reflects the benchmarked traffic and can be copied next to main.go for
+// the next build.
+func handlePGOProfile(path string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		seconds := defaultPGOSeconds
+		if raw := c.Query("seconds"); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil || n < 1 || n > maxPGOSeconds {
+				errorJSON(c, http.StatusBadRequest, "seconds must be between 1 and 300", "")
+				return
+			}
+			seconds = n
+		}
+		window := time.Duration(seconds) * time.Second
+
+		// The admin listener's WriteTimeout is shorter than most windows.
+		_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Now().Add(window + 10*time.Second))
+
+		// Write to a temporary file and rename it, so a build never picks
+		// up a half-written profile.
+		tmp, err := os.CreateTemp(filepath.Dir(path), ".default.pgo-*")
+		if err != nil {
+			errorJSON(c, http.StatusInternalServerError, "Failed to create profile", err.Error())
+			return
+		}
+		defer os.Remove(tmp.Name())
+
+		if err := pprof.StartCPUProfile(tmp); err != nil {
+			tmp.Close()
+			// Only one CPU profile can run per process.
+			errorJSON(c, http.StatusConflict, "CPU profile already in progress", "")
+			return
+		}
+
+		select {
+		case <-time.After(window):
+		case <-c.Request.Context().Done():
+			pprof.StopCPUProfile()
+			tmp.Close()
+			return
+		}
+		pprof.StopCPUProfile()
+
+		info, err := tmp.Stat()
+		if err == nil {
+			err = tmp.Close()
+		} else {
+			tmp.Close()
+		}
+		if err == nil {
+			err = os.Rename(tmp.Name(), path)
+		}
+		if err != nil {
+			errorJSON(c, http.StatusInternalServerError, "Failed to write profile", err.Error())
+			return
+		}
+
+		c.JSON(http.StatusOK, PGOProfileResponse{
+			Path:    path,
+			Seconds: seconds,
+			Bytes:   info.Size(),
+		})
+	}
+}
+
+// pgoBuildProfile returns the profile this binary was compiled with, as
+// recorded by the toolchain in the build info, or "off".
+func pgoBuildProfile() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range info.Settings {
+			if s.Key == "-pgo" && s.Value != "" {
+				return filepath.Base(s.Value)
+			}
+		}
+	}
+	return "off"
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// failingRepo answers GetByID with err and everything else from the
+// memory repository.
+type failingRepo struct {
+	*memoryUserRepository
+	err error
+}
+
+func (r failingRepo) GetByID(ctx context.Context, id int) (User, error) {
+	return User{}, r.err
+}
+
+func TestPoolExhaustedResponse(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		want           int
+		wantRetryAfter string
+	}{
+		{"pool exhausted", fmt.Errorf("%w: %w", ErrPoolExhausted, context.DeadlineExceeded), http.StatusServiceUnavailable, "1"},
+		{"slow query", context.DeadlineExceeded, http.StatusGatewayTimeout, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := testFeatures(t, nil)
+			r := testRouter(failingRepo{newMemoryUserRepository(f.MemorySeedSize), tt.err}, f)
+			w := doRequest(r, http.MethodGet, "/users/1", "")
+
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+			if got := w.Header().Get("Retry-After"); got != tt.wantRetryAfter {
+				t.Errorf("Retry-After = %q, want %q", got, tt.wantRetryAfter)
+			}
+		})
+	}
+}
+
+// TestPoolExhausted saturates a 1-connection pool and checks that a query
+// waiting for it past DB_QUERY_TIMEOUT answers 503 rather than 500 or 504.
+// It needs PostgreSQL at TEST_DATABASE_URL with the users table seeded.
+func TestPoolExhausted(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	t.Setenv("DATABASE_URL", dsn)
+
+	db := setupDB(Features{
+		DBMaxOpenConns:   1,
+		DBMaxIdleConns:   1,
+		DBStartupTimeout: flagDuration(5 * time.Second),
+	}, nil)
+	t.Cleanup(db.Close)
+
+	repo := withQueryTimeout(newSQLUserRepository(db, false), 200*time.Millisecond)
+	r := testRouter(repo, testFeatures(t, nil))
+
+	conn, err := db.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	w := doRequest(r, http.MethodGet, "/users/1", "")
+	conn.Release()
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("saturated: status = %d, want %d: %s", w.Code, http.StatusServiceUnavailable, w.Body)
+	}
+	if got := w.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("saturated: Retry-After = %q, want 1", got)
+	}
+
+	if w := doRequest(r, http.MethodGet, "/users/1", ""); w.Code != http.StatusOK {
+		t.Errorf("released: status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+}
+
+func TestPoolCounters(t *testing.T) {
+	cfg, err := pgxpool.ParseConfig("postgres://benchmark@127.0.0.1:1/benchmark")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := pgxpool.NewWithConfig(context.Background(), cfg) // connects lazily
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	recordPoolStats(db.Stat())
+	want := `
+# HELP gin_db_pool_acquires_total Total number of successful connection acquires.
+# TYPE gin_db_pool_acquires_total counter
+gin_db_pool_acquires_total 0
+# HELP gin_db_pool_waits_total Total number of acquires that waited for a connection because none was idle.
+# TYPE gin_db_pool_waits_total counter
+gin_db_pool_waits_total 0
+`
+	if err := testutil.CollectAndCompare(poolCounters, strings.NewReader(want),
+		"gin_db_pool_acquires_total", "gin_db_pool_waits_total"); err != nil {
+		t.Error(err)
+	}
+	if n := testutil.CollectAndCount(poolCounters); n != 6 {
+		t.Errorf("collected %d pool counters, want 6", n)
+	}
+}
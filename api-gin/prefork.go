@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Prefork (PREFORK=1)
+// ---------------------------------------------------------------------------
+
+// preforkChildEnv marks a process started by runPreforkMaster.
+const preforkChildEnv = "PREFORK_CHILD"
+
+func isPreforkChild() bool {
+	return os.Getenv(preforkChildEnv) == "1"
+}
+
+// runPreforkMaster starts n copies of this binary, each with GOMAXPROCS=1,
+// listening on the same port with SO_REUSEPORT, the way Fiber's Prefork
+// does. The master itself opens no database pool and serves nothing: it
+// forwards SIGINT/SIGTERM to the children, so each drains and shuts down as
+// a single process would, and returns once all of them have exited. If a
+// child dies on its own the others are stopped and an error is returned.
+// With tls, SIGHUP is forwarded as well so every child reloads its
+// certificate; otherwise it would terminate them.
+func runPreforkMaster(n int, tls bool) error {
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	hup := make(chan os.Signal, 1)
+	if tls {
+		signal.Notify(hup, syscall.SIGHUP)
+	}
+
+	children := make([]*exec.Cmd, 0, n)
+	exited := make(chan error, n)
+	signalAll := func(sig os.Signal) {
+		for _, cmd := range children {
+			_ = cmd.Process.Signal(sig)
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		cmd := exec.Command(os.Args[0], os.Args[1:]...)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		cmd.Env = append(os.Environ(), preforkChildEnv+"=1", "GOMAXPROCS=1")
+		if err := cmd.Start(); err != nil {
+			signalAll(syscall.SIGTERM)
+			return fmt.Errorf("start child %d: %w", i, err)
+		}
+		children = append(children, cmd)
+		go func() { exited <- cmd.Wait() }()
+	}
+	log.Printf("prefork: started %d child processes", n)
+
+	remaining := n
+	stopping := false
+	var childErr error
+	for remaining > 0 {
+		select {
+		case <-hup:
+			signalAll(syscall.SIGHUP)
+		case sig := <-quit:
+			// A second signal is forwarded too, cutting the children's
+			// drain delay short.
+			stopping = true
+			signalAll(sig)
+		case err := <-exited:
+			remaining--
+			if !stopping {
+				stopping = true
+				childErr = fmt.Errorf("child exited unexpectedly: %v", err)
+				log.Printf("prefork: %v; stopping the others", childErr)
+				signalAll(syscall.SIGTERM)
+			}
+		}
+	}
+	return childErr
+}
+
+// watchPreforkMaster exits the child once its master is gone (reparented),
+// so a killed master does not leave orphans holding the port.
+func watchPreforkMaster() {
+	master := os.Getppid()
+	for range time.Tick(500 * time.Millisecond) {
+		if os.Getppid() != master {
+			log.Println("prefork: master exited, stopping")
+			os.Exit(1)
+		}
+	}
+}
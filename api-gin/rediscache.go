@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/rand/v2"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ---------------------------------------------------------------------------
+// Redis cache-aside (CACHE_URL)
+// ---------------------------------------------------------------------------
+
+// redisUserRepository reads users by id through Redis: GET /users/:id and
+// /db (by id) look up user:<id> first and, on a miss, read the database and
+// store the row for ttl. Every write that can change a row deletes its key
+// after the write, so the next read repopulates it. Creates need no
+// invalidation, as lookups of missing ids are not cached. Redis failures
+// fall back to the database rather than failing the request.
+//
+// /db?mode=scan, ?sample=system and the multi-row routes bypass the cache.
+type redisUserRepository struct {
+	UserRepository
+	rdb   *redis.Client
+	ttl   time.Duration
+	maxID int
+}
+
+// cachedUserRecord is the value stored under user:<id>. User does not
+// serialize Version, which GET /users/:id needs for its ETag.
+type cachedUserRecord struct {
+	User
+	Version int `json:"version"`
+}
+
+func withRedisCache(repo UserRepository, rdb *redis.Client, ttl time.Duration, maxID int) UserRepository {
+	return &redisUserRepository{UserRepository: repo, rdb: rdb, ttl: ttl, maxID: maxID}
+}
+
+// setupCache connects to the Redis instance in CACHE_URL.
+func setupCache(rawURL string) (*redis.Client, error) {
+	opts, err := redis.ParseURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	rdb := redis.NewClient(opts)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := rdb.Ping(ctx).Err(); err != nil {
+		rdb.Close()
+		return nil, err
+	}
+	return rdb, nil
+}
+
+func userCacheKey(id int) string {
+	return "user:" + strconv.Itoa(id)
+}
+
+func (r *redisUserRepository) GetByID(ctx context.Context, id int) (User, error) {
+	key := userCacheKey(id)
+	if raw, err := r.rdb.Get(ctx, key).Bytes(); err == nil {
+		var rec cachedUserRecord
+		if json.Unmarshal(raw, &rec) == nil {
+			user := rec.User
+			user.Version = rec.Version
+			return user, nil
+		}
+	}
+
+	user, err := r.UserRepository.GetByID(ctx, id)
+	if err != nil {
+		return User{}, err
+	}
+	if raw, err := json.Marshal(cachedUserRecord{User: user, Version: user.Version}); err == nil {
+		r.rdb.Set(ctx, key, raw, r.ttl)
+	}
+	return user, nil
+}
+
+// Random by id draws the id here, as the SQL repository does, so the
+// lookup can go through the cache. An id deleted since startup falls back
+// to the wrapped repository.
+func (r *redisUserRepository) Random(ctx context.Context, mode RandomMode) (User, error) {
+	if mode != RandomByID || r.maxID == 0 {
+		return r.UserRepository.Random(ctx, mode)
+	}
+	user, err := r.GetByID(ctx, rand.IntN(r.maxID)+1)
+	if errors.Is(err, ErrNotFound) {
+		return r.UserRepository.Random(ctx, mode)
+	}
+	return user, err
+}
+
+// invalidate drops the cached rows of ids. It runs after the write whatever
+// its outcome: a failed write leaves the row unchanged and only costs a miss.
+func (r *redisUserRepository) invalidate(ctx context.Context, ids ...int) {
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = userCacheKey(id)
+	}
+	// Detached, so a client disconnecting right after the write cannot
+	// leave a stale entry behind.
+	r.rdb.Del(context.WithoutCancel(ctx), keys...)
+}
+
+func (r *redisUserRepository) Update(ctx context.Context, id int, req UpdateUserRequest, version int) (User, error) {
+	user, err := r.UserRepository.Update(ctx, id, req, version)
+	r.invalidate(ctx, id)
+	return user, err
+}
+
+func (r *redisUserRepository) UpdateMany(ctx context.Context, reqs []BulkUpdateUserRequest) ([]User, error) {
+	users, err := r.UserRepository.UpdateMany(ctx, reqs)
+	ids := make([]int, len(reqs))
+	for i, req := range reqs {
+		ids[i] = req.ID
+	}
+	r.invalidate(ctx, ids...)
+	return users, err
+}
+
+func (r *redisUserRepository) Replace(ctx context.Context, u User) (User, error) {
+	user, err := r.UserRepository.Replace(ctx, u)
+	r.invalidate(ctx, u.ID)
+	return user, err
+}
+
+func (r *redisUserRepository) Delete(ctx context.Context, id int) error {
+	err := r.UserRepository.Delete(ctx, id)
+	r.invalidate(ctx, id)
+	return err
+}
@@ -0,0 +1,693 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ---------------------------------------------------------------------------
+// Repository
+// ---------------------------------------------------------------------------
+
+// Errors returned by UserRepository implementations. Handlers map them to
+// HTTP statuses; other fronts can map them to their own error codes.
+var (
+	// ErrNotFound means the addressed user (or any user, for Random) is absent.
+	ErrNotFound = errors.New("user not found")
+	// ErrEmailTaken means the write violated the unique email constraint.
+	// Null emails never collide: PostgreSQL treats NULLs as distinct.
+	ErrEmailTaken = errors.New("email already in use")
+	// ErrVersionMismatch means a conditional update targeted a stale version.
+	ErrVersionMismatch = errors.New("user version mismatch")
+	// ErrPoolExhausted means the context expired while every pooled
+	// connection was busy: the server is overloaded, the database is not
+	// failing.
+	ErrPoolExhausted = errors.New("connection pool exhausted")
+	// ErrNullValue means the write left a NOT NULL column null, e.g. a patch
+	// removing the email on a schema that requires one.
+	ErrNullValue = errors.New("required value is null")
+	// ErrInvalidReference means the write broke a foreign key: it points at
+	// a row that does not exist, or removes one that is still referenced.
+	ErrInvalidReference = errors.New("foreign key violation")
+)
+
+// BulkUpdateError names the user whose update aborted an UpdateMany batch.
+// Err is ErrNotFound, ErrEmailTaken or the underlying database error.
+type BulkUpdateError struct {
+	ID  int
+	Err error
+}
+
+func (e *BulkUpdateError) Error() string {
+	return fmt.Sprintf("user %d: %v", e.ID, e.Err)
+}
+
+func (e *BulkUpdateError) Unwrap() error { return e.Err }
+
+// UserRepository is the data-access boundary for the users table, decoupled
+// from Gin so it can be reused by other benchmark fronts and tested alone.
+type UserRepository interface {
+	// Random returns one random user.
+	Random(ctx context.Context, mode RandomMode) (User, error)
+	// RandomSampled returns one user picked by block sampling, falling back
+	// to a scan when the sample comes back empty.
+	RandomSampled(ctx context.Context) (User, error)
+	// RandomN returns up to n distinct random users in a single query.
+	RandomN(ctx context.Context, n int, mode RandomMode) ([]User, error)
+	// RandomPinned runs n single-row random queries on one dedicated
+	// connection instead of letting the pool hand out different ones.
+	RandomPinned(ctx context.Context, n int, mode RandomMode) ([]User, error)
+	// RandomBatched runs n single-row random queries, sent to the database
+	// together in a single round trip.
+	RandomBatched(ctx context.Context, n int, mode RandomMode) ([]User, error)
+	// List returns users in the given order; limit 0 means no limit.
+	List(ctx context.Context, order UserOrder, limit, offset int) ([]User, error)
+	// Stream calls fn for each user in the given order without buffering
+	// the result set; limit 0 means no limit. A non-nil error from fn stops it.
+	Stream(ctx context.Context, order UserOrder, limit, offset int, fn func(User) error) error
+	// Count returns the number of users.
+	Count(ctx context.Context) (int, error)
+	// Stats computes aggregate age statistics in a single query.
+	Stats(ctx context.Context) (UserStats, error)
+	// GetByID returns the user with its Version populated.
+	GetByID(ctx context.Context, id int) (User, error)
+	// Create inserts a user and returns the stored row.
+	Create(ctx context.Context, req CreateUserRequest) (User, error)
+	// CreateMinimal inserts a user and returns only its id.
+	CreateMinimal(ctx context.Context, req CreateUserRequest) (int, error)
+	// Update applies the non-nil fields of req. When version is non-zero the
+	// update only succeeds if the row still has that version.
+	Update(ctx context.Context, id int, req UpdateUserRequest, version int) (User, error)
+	// UpdateMany applies each element's non-nil fields in order, all or
+	// nothing, and returns the updated users in request order. A failing
+	// element is reported as *BulkUpdateError.
+	UpdateMany(ctx context.Context, reqs []BulkUpdateUserRequest) ([]User, error)
+	// Replace overwrites name, email and age (including a null age) of
+	// u.ID, provided the row still has u.Version.
+	Replace(ctx context.Context, u User) (User, error)
+	// Delete removes the user.
+	Delete(ctx context.Context, id int) error
+}
+
+// RandomMode selects how random users are picked.
+type RandomMode int
+
+const (
+	// RandomByID looks users up by primary key, with ids drawn uniformly
+	// from the seeded range. The default.
+	RandomByID RandomMode = iota
+	// RandomScan uses ORDER BY RANDOM(), which scans and sorts the whole
+	// table on every query (?mode=scan).
+	RandomScan
+)
+
+// parseRandomMode parses ?mode: "" for RandomByID, "scan" for RandomScan.
+func parseRandomMode(raw string) (RandomMode, bool) {
+	switch raw {
+	case "":
+		return RandomByID, true
+	case "scan":
+		return RandomScan, true
+	}
+	return 0, false
+}
+
+// randomIDs draws n distinct ids from 1..max with Floyd's algorithm; n must
+// not exceed max.
+func randomIDs(n, max int) []int {
+	ids := make([]int, 0, n)
+	picked := make(map[int]bool, n)
+	for j := max - n + 1; j <= max; j++ {
+		t := rand.IntN(j) + 1
+		if picked[t] {
+			t = j
+		}
+		picked[t] = true
+		ids = append(ids, t)
+	}
+	return ids
+}
+
+// UserStats is the aggregate returned by GET /users/stats. The age fields
+// are null when there are no non-null ages (e.g. an empty table).
+type UserStats struct {
+	Count        int      `json:"count"`
+	AvgAge       *float64 `json:"avg_age"`
+	MinAge       *int     `json:"min_age"`
+	MaxAge       *int     `json:"max_age"`
+	NullAgeCount int      `json:"null_age_count"`
+}
+
+// UserOrder is a validated sort order for user listings. The zero value
+// orders by id ascending.
+//
+// Convention: every ordered query ends with the unique id column as the
+// final tie-break, so results (and therefore pages) are stable even when
+// sorting by a non-unique column such as age.
+type UserOrder struct {
+	Column string // one of userSortColumns; "" means id
+	Desc   bool
+}
+
+// userSortColumns whitelists the columns accepted by ?sort=. The names are
+// interpolated into SQL, so nothing outside this set may reach a query.
+var userSortColumns = map[string]bool{
+	"id":         true,
+	"name":       true,
+	"email":      true,
+	"age":        true,
+	"created_at": true,
+}
+
+// parseUserOrder parses a ?sort value such as "age" or "-created_at"
+// (leading "-" for descending). ok is false for unknown columns.
+func parseUserOrder(raw string) (order UserOrder, ok bool) {
+	if raw == "" {
+		return UserOrder{}, true
+	}
+	if strings.HasPrefix(raw, "-") {
+		order.Desc = true
+		raw = raw[1:]
+	}
+	if !userSortColumns[raw] {
+		return UserOrder{}, false
+	}
+	order.Column = raw
+	return order, true
+}
+
+// orderBy renders the ORDER BY clause, including the id tie-break.
+func (o UserOrder) orderBy() string {
+	col := o.Column
+	if col == "" {
+		col = "id"
+	}
+	dir := ""
+	if o.Desc {
+		dir = " DESC"
+	}
+	if col == "id" {
+		return "ORDER BY id" + dir
+	}
+	return "ORDER BY " + col + dir + ", id" + dir
+}
+
+// listQuery returns the list (or page, when paged) query for the order. The
+// default order uses the precomputed constants to keep the hot path free of
+// string building.
+func (o UserOrder) listQuery(paged bool) string {
+	if o == (UserOrder{}) {
+		if paged {
+			return queryPageUsers
+		}
+		return queryListUsers
+	}
+	if paged {
+		return selectUsers + " " + o.orderBy() + " LIMIT $1 OFFSET $2"
+	}
+	return selectUsers + " " + o.orderBy()
+}
+
+// sqlUserRepository implements UserRepository on a pgxpool pool
+// (PostgreSQL). Whether statements are prepared and cached per connection
+// is a property of the pool (see setupDB); the repository only has to know
+// whether the hotStatements were prepared, to execute them by name.
+type sqlUserRepository struct {
+	db    pgxPool
+	names map[string]string // query text -> prepared statement name
+	maxID int               // random ids are drawn from 1..maxID; 0 means unknown
+}
+
+// pgxPool is the part of *pgxpool.Pool the SQL repository uses, so its tests
+// can run it against pgxmock instead of a database.
+type pgxPool interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Acquire(ctx context.Context) (*pgxpool.Conn, error)
+	Stat() *pgxpool.Stat
+}
+
+func newSQLUserRepository(db pgxPool, preparedHot bool) *sqlUserRepository {
+	r := &sqlUserRepository{db: db}
+	if preparedHot {
+		r.names = make(map[string]string, len(hotStatements))
+		for _, st := range hotStatements {
+			r.names[st.query] = st.name
+		}
+	}
+	return r
+}
+
+// hotStatements are the queries behind /db, /queries, /users/:id and the
+// writes.
+// With DB_PREPARED_STATEMENT_CACHE=1 every pooled connection prepares them by
+// name as it opens, and the repository executes them by that name, so they
+// are parsed and planned once per connection rather than per request.
+var hotStatements = []struct{ name, query string }{
+	{"random_user", queryRandomUser},
+	{"random_user_by_id", queryRandomUserByID},
+	{"random_users_by_id", queryRandomUsersByID},
+	{"get_user", queryGetUser},
+	{"create_user", queryCreateUser},
+	{"create_user_minimal", queryCreateUserMinimal},
+	{"update_user", queryUpdateUser},
+	{"update_user_if_version", queryUpdateUserIfVersion},
+	{"delete_user", queryDeleteUser},
+}
+
+// prepareHotStatements is the pool's AfterConnect hook. A failure discards
+// the connection, so no pooled connection lacks the statements.
+func prepareHotStatements(ctx context.Context, conn *pgx.Conn) error {
+	for _, st := range hotStatements {
+		if _, err := conn.Prepare(ctx, st.name, st.query); err != nil {
+			return fmt.Errorf("prepare %s: %w", st.name, err)
+		}
+	}
+	return nil
+}
+
+// sql returns what to execute for query: the name it was prepared under,
+// or the query text itself when it is ad hoc. pgx runs a prepared statement
+// when handed its name.
+func (r *sqlUserRepository) sql(query string) string {
+	if name, ok := r.names[query]; ok {
+		return name
+	}
+	return query
+}
+
+const (
+	selectUsers            = `SELECT id, name, email, age, created_at FROM users`
+	queryRandomUser        = `SELECT id, name, email, age, created_at FROM users ORDER BY RANDOM() LIMIT 1`
+	queryRandomUserSampled = `SELECT id, name, email, age, created_at FROM users TABLESAMPLE SYSTEM (1) LIMIT 1`
+	queryRandomUsers       = `SELECT id, name, email, age, created_at FROM users ORDER BY RANDOM() LIMIT $1`
+	queryRandomUserByID    = `SELECT id, name, email, age, created_at FROM users WHERE id = $1`
+	queryRandomUsersByID   = `SELECT id, name, email, age, created_at FROM users WHERE id = ANY($1)`
+	queryMaxUserID         = `SELECT COALESCE(MAX(id), 0) FROM users`
+	queryListUsers         = `SELECT id, name, email, age, created_at FROM users ORDER BY id`
+	queryPageUsers         = `SELECT id, name, email, age, created_at FROM users ORDER BY id LIMIT $1 OFFSET $2`
+	queryCountUsers        = `SELECT COUNT(*)::int FROM users`
+	queryUserStats         = `SELECT COUNT(*)::int, AVG(age)::float8, MIN(age), MAX(age), (COUNT(*) - COUNT(age))::int FROM users`
+	queryGetUser           = `SELECT id, name, email, age, created_at, version FROM users WHERE id = $1`
+	queryUserExists        = `SELECT EXISTS (SELECT 1 FROM users WHERE id = $1)`
+	queryDeleteUser        = `DELETE FROM users WHERE id = $1 RETURNING id`
+
+	queryCreateUser = `
+		INSERT INTO users (name, email, age)
+		VALUES ($1, $2, $3)
+		RETURNING id, name, email, age, created_at`
+	queryCreateUserMinimal = `
+		INSERT INTO users (name, email, age)
+		VALUES ($1, $2, $3)
+		RETURNING id`
+
+	// Uses COALESCE to update only provided fields in a single query.
+	// Same SQL pattern used by all 5 frameworks for fair comparison.
+	queryUpdateUser = `
+		UPDATE users
+		SET name    = COALESCE($1, name),
+		    email   = COALESCE($2, email),
+		    age     = COALESCE($3, age),
+		    version = version + 1
+		WHERE id = $4
+		RETURNING id, name, email, age, created_at, version`
+	queryUpdateUserIfVersion = `
+		UPDATE users
+		SET name    = COALESCE($1, name),
+		    email   = COALESCE($2, email),
+		    age     = COALESCE($3, age),
+		    version = version + 1
+		WHERE id = $4 AND version = $5
+		RETURNING id, name, email, age, created_at, version`
+	queryReplaceUser = `
+		UPDATE users
+		SET name    = $1,
+		    email   = $2,
+		    age     = $3,
+		    version = version + 1
+		WHERE id = $4 AND version = $5
+		RETURNING id, name, email, age, created_at, version`
+)
+
+// rowQuerier is what randomOne needs from the pool or a pinned connection.
+type rowQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// loadIDRange records the highest user id, the range random ids are drawn
+// from. The seed (scripts/init.sql) inserts users 1..N with no gaps, so the
+// range is the seeded row count; users created later are not drawn.
+func (r *sqlUserRepository) loadIDRange(ctx context.Context) (int, error) {
+	err := r.db.QueryRow(ctx, queryMaxUserID).Scan(&r.maxID)
+	return r.maxID, err
+}
+
+// randomOne picks one user on q. By id it is a primary-key lookup of an id
+// drawn from the seeded range; an id that no longer exists (deleted since
+// startup), or an unknown range, falls back to the scan.
+func (r *sqlUserRepository) randomOne(ctx context.Context, q rowQuerier, mode RandomMode) (User, error) {
+	if mode == RandomByID && r.maxID > 0 {
+		user, err := scanUser(q.QueryRow(ctx, r.sql(queryRandomUserByID), rand.IntN(r.maxID)+1).Scan)
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return user, r.poolErr(err)
+		}
+	}
+	user, err := scanUser(q.QueryRow(ctx, r.sql(queryRandomUser)).Scan)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return User{}, ErrNotFound
+	}
+	return user, r.poolErr(err)
+}
+
+func (r *sqlUserRepository) Random(ctx context.Context, mode RandomMode) (User, error) {
+	return r.randomOne(ctx, r.db, mode)
+}
+
+// RandomSampled avoids the full scan and sort of ORDER BY RANDOM() by reading
+// a ~1% sample of the table's pages. Caveats of block sampling: SYSTEM picks
+// whole pages, so rows sharing a page are selected together (correlated),
+// and LIMIT 1 always returns the first row of the first sampled page, which
+// biases the pick toward rows stored early in each page. Small tables may
+// yield an empty sample, in which case this falls back to the scan.
+func (r *sqlUserRepository) RandomSampled(ctx context.Context) (User, error) {
+	user, err := scanUser(r.db.QueryRow(ctx, queryRandomUserSampled).Scan)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return r.Random(ctx, RandomScan)
+	}
+	return user, r.poolErr(err)
+}
+
+// RandomN by id fetches n distinct ids from the seeded range with one
+// = ANY($1) lookup. If some of them no longer exist, or the range holds
+// fewer than n ids, it falls back to the scan.
+func (r *sqlUserRepository) RandomN(ctx context.Context, n int, mode RandomMode) ([]User, error) {
+	if mode == RandomByID && r.maxID >= n {
+		users, err := r.collect(ctx, getUsers(n), r.sql(queryRandomUsersByID), randomIDs(n, r.maxID))
+		if err != nil || len(users) == n {
+			return users, err
+		}
+		putUsers(users)
+	}
+	return r.collect(ctx, getUsers(n), queryRandomUsers, n)
+}
+
+func (r *sqlUserRepository) RandomPinned(ctx context.Context, n int, mode RandomMode) ([]User, error) {
+	conn, err := r.db.Acquire(ctx)
+	if err != nil {
+		return nil, r.poolErr(err)
+	}
+	// Release returns the connection to the pool on every path, including
+	// errors and context cancellation mid-loop.
+	defer conn.Release()
+
+	users := getUsers(n)
+	for i := 0; i < n; i++ {
+		user, err := r.randomOne(ctx, conn, mode)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+// RandomBatched queues the n single-row queries of conn=per-row in one pgx
+// batch. pgx pipelines the whole batch, so the statements still run one by
+// one on the server but cost a single network round trip, isolating the
+// round-trip cost from that of the extra statements. Ids that no longer
+// exist are re-picked by scan after the batch.
+func (r *sqlUserRepository) RandomBatched(ctx context.Context, n int, mode RandomMode) ([]User, error) {
+	byID := mode == RandomByID && r.maxID > 0
+	batch := &pgx.Batch{}
+	for i := 0; i < n; i++ {
+		if byID {
+			batch.Queue(r.sql(queryRandomUserByID), rand.IntN(r.maxID)+1)
+		} else {
+			batch.Queue(r.sql(queryRandomUser))
+		}
+	}
+	br := r.db.SendBatch(ctx, batch)
+	// Close releases the connection on the early returns; a no-op after the
+	// explicit Close below.
+	defer br.Close()
+
+	users := getUsers(n)[:n]
+	var missed []int
+	for i := range users {
+		user, err := scanUser(br.QueryRow().Scan)
+		switch {
+		case errors.Is(err, pgx.ErrNoRows) && byID:
+			missed = append(missed, i)
+		case errors.Is(err, pgx.ErrNoRows):
+			return nil, ErrNotFound
+		case err != nil:
+			return nil, r.poolErr(err)
+		}
+		users[i] = user
+	}
+	if err := br.Close(); err != nil {
+		return nil, r.poolErr(err)
+	}
+
+	for _, i := range missed {
+		user, err := r.Random(ctx, RandomScan)
+		if err != nil {
+			return nil, err
+		}
+		users[i] = user
+	}
+	return users, nil
+}
+
+func (r *sqlUserRepository) List(ctx context.Context, order UserOrder, limit, offset int) ([]User, error) {
+	if limit == 0 {
+		return r.collect(ctx, make([]User, 0), order.listQuery(false))
+	}
+	return r.collect(ctx, make([]User, 0, limit), order.listQuery(true), limit, offset)
+}
+
+func (r *sqlUserRepository) Stream(ctx context.Context, order UserOrder, limit, offset int, fn func(User) error) error {
+	var rows pgx.Rows
+	var err error
+	if limit == 0 {
+		rows, err = r.db.Query(ctx, order.listQuery(false))
+	} else {
+		rows, err = r.db.Query(ctx, order.listQuery(true), limit, offset)
+	}
+	if err != nil {
+		return r.poolErr(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		user, err := scanUser(rows.Scan)
+		if err != nil {
+			return r.poolErr(err)
+		}
+		if err := fn(user); err != nil {
+			return r.poolErr(err)
+		}
+	}
+	return rows.Err()
+}
+
+func (r *sqlUserRepository) Count(ctx context.Context) (int, error) {
+	var total int
+	err := r.db.QueryRow(ctx, queryCountUsers).Scan(&total)
+	return total, r.poolErr(err)
+}
+
+func (r *sqlUserRepository) Stats(ctx context.Context) (UserStats, error) {
+	var st UserStats
+	err := r.db.QueryRow(ctx, queryUserStats).Scan(&st.Count, &st.AvgAge, &st.MinAge, &st.MaxAge, &st.NullAgeCount)
+	return st, r.poolErr(err)
+}
+
+func (r *sqlUserRepository) GetByID(ctx context.Context, id int) (User, error) {
+	user, err := scanVersionedUser(r.db.QueryRow(ctx, r.sql(queryGetUser), id).Scan)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return User{}, ErrNotFound
+	}
+	return user, r.poolErr(err)
+}
+
+func (r *sqlUserRepository) Create(ctx context.Context, req CreateUserRequest) (User, error) {
+	row := r.db.QueryRow(ctx, r.sql(queryCreateUser), req.Name, req.Email, req.Age)
+	user, err := scanUser(row.Scan)
+	return user, r.writeErr(err)
+}
+
+func (r *sqlUserRepository) CreateMinimal(ctx context.Context, req CreateUserRequest) (int, error) {
+	var id int
+	err := r.db.QueryRow(ctx, r.sql(queryCreateUserMinimal), req.Name, req.Email, req.Age).Scan(&id)
+	return id, r.writeErr(err)
+}
+
+func (r *sqlUserRepository) Update(ctx context.Context, id int, req UpdateUserRequest, version int) (User, error) {
+	var row pgx.Row
+	if version != 0 {
+		row = r.db.QueryRow(ctx, r.sql(queryUpdateUserIfVersion), req.Name, req.Email, req.Age, id, version)
+	} else {
+		row = r.db.QueryRow(ctx, r.sql(queryUpdateUser), req.Name, req.Email, req.Age, id)
+	}
+
+	user, err := scanVersionedUser(row.Scan)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows) && version != 0:
+		// Zero rows: either the row is gone or its version moved on.
+		var exists bool
+		if err := r.db.QueryRow(ctx, queryUserExists, id).Scan(&exists); err != nil {
+			return User{}, r.poolErr(err)
+		}
+		if exists {
+			return User{}, ErrVersionMismatch
+		}
+		return User{}, ErrNotFound
+	case errors.Is(err, pgx.ErrNoRows):
+		return User{}, ErrNotFound
+	}
+	return user, r.writeErr(err)
+}
+
+// UpdateMany runs the per-row COALESCE update for every element inside one
+// transaction. Row by row (rather than a single UPDATE ... FROM (VALUES ...))
+// so a unique violation or a missing row can be pinned to its id; any
+// failure rolls the whole batch back.
+func (r *sqlUserRepository) UpdateMany(ctx context.Context, reqs []BulkUpdateUserRequest) ([]User, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, r.poolErr(err)
+	}
+	// A no-op once Commit has succeeded.
+	defer tx.Rollback(ctx)
+
+	users := make([]User, 0, len(reqs))
+	for _, req := range reqs {
+		user, err := scanVersionedUser(tx.QueryRow(ctx, r.sql(queryUpdateUser), req.Name, req.Email, req.Age, req.ID).Scan)
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return nil, &BulkUpdateError{ID: req.ID, Err: ErrNotFound}
+		case err != nil:
+			return nil, &BulkUpdateError{ID: req.ID, Err: r.writeErr(err)}
+		}
+		users = append(users, user)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, r.poolErr(err)
+	}
+	return users, nil
+}
+
+func (r *sqlUserRepository) Replace(ctx context.Context, u User) (User, error) {
+	row := r.db.QueryRow(ctx, queryReplaceUser, u.Name, u.Email, u.Age, u.ID, u.Version)
+	user, err := scanVersionedUser(row.Scan)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		var exists bool
+		if err := r.db.QueryRow(ctx, queryUserExists, u.ID).Scan(&exists); err != nil {
+			return User{}, r.poolErr(err)
+		}
+		if exists {
+			return User{}, ErrVersionMismatch
+		}
+		return User{}, ErrNotFound
+	}
+	return user, r.writeErr(err)
+}
+
+func (r *sqlUserRepository) Delete(ctx context.Context, id int) error {
+	var deletedID int
+	err := r.db.QueryRow(ctx, r.sql(queryDeleteUser), id).Scan(&deletedID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+	return r.writeErr(err)
+}
+
+// poolErr tags a deadline hit while the pool was saturated as
+// ErrPoolExhausted. pgxpool reports a timed-out wait for a connection as the
+// bare context error, so the pool state is what tells it apart from a query
+// that was itself too slow.
+func (r *sqlUserRepository) poolErr(err error) error {
+	if err == nil || !errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	st := r.db.Stat()
+	if st.AcquiredConns() >= st.MaxConns() {
+		return fmt.Errorf("%w: %w", ErrPoolExhausted, err)
+	}
+	return err
+}
+
+// collect runs a multi-row user query and appends the rows to users, which
+// the caller preallocates (from getUsers on the /queries paths).
+func (r *sqlUserRepository) collect(ctx context.Context, users []User, query string, args ...any) ([]User, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, r.poolErr(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		user, err := scanUser(rows.Scan)
+		if err != nil {
+			return nil, r.poolErr(err)
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, r.poolErr(err)
+	}
+	return users, nil
+}
+
+// Integrity-constraint SQLSTATEs mapped by writeErr.
+const (
+	sqlStateNotNullViolation    = "23502"
+	sqlStateForeignKeyViolation = "23503"
+	sqlStateUniqueViolation     = "23505"
+)
+
+// writeErr maps the integrity-constraint violations a write can raise onto
+// the repository errors, unwrapping pgx's *pgconn.PgError for its SQLSTATE.
+// Anything else goes through poolErr. The only unique constraint on users is
+// the email.
+func (r *sqlUserRepository) writeErr(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case sqlStateUniqueViolation:
+			return ErrEmailTaken
+		case sqlStateNotNullViolation:
+			return fmt.Errorf("%w: column %s", ErrNullValue, pgErr.ColumnName)
+		case sqlStateForeignKeyViolation:
+			return fmt.Errorf("%w: constraint %s", ErrInvalidReference, pgErr.ConstraintName)
+		}
+	}
+	return r.poolErr(err)
+}
+
+// scanUser reads a single User from any pgx.Row / pgx.Rows via the scan func.
+func scanUser(scan func(...any) error) (User, error) {
+	var u User
+	err := scan(&u.ID, &u.Name, &u.Email, &u.Age, &u.CreatedAt)
+	return u, err
+}
+
+// scanVersionedUser is scanUser for queries that also select the version
+// column (used to derive the ETag).
+func scanVersionedUser(scan func(...any) error) (User, error) {
+	var u User
+	err := scan(&u.ID, &u.Name, &u.Email, &u.Age, &u.CreatedAt, &u.Version)
+	return u, err
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v3"
+)
+
+// newMockRepository returns a SQL repository on a pgxmock pool that matches
+// query text exactly; the expectations must all be met by the end of t.
+func newMockRepository(t *testing.T, preparedHot bool) (*sqlUserRepository, pgxmock.PgxPoolIface) {
+	t.Helper()
+	mock, err := pgxmock.NewPool(pgxmock.QueryMatcherOption(pgxmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("pgxmock.NewPool: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+		mock.Close()
+	})
+	return newSQLUserRepository(mock, preparedHot), mock
+}
+
+var (
+	userColumns          = []string{"id", "name", "email", "age", "created_at"}
+	versionedUserColumns = append(userColumns[:len(userColumns):len(userColumns)], "version")
+	testCreatedAt        = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+)
+
+func TestSQLGetByID(t *testing.T) {
+	email, age := "ada@example.com", 36
+
+	t.Run("found", func(t *testing.T) {
+		repo, mock := newMockRepository(t, false)
+		mock.ExpectQuery(queryGetUser).WithArgs(1).WillReturnRows(
+			mock.NewRows(versionedUserColumns).AddRow(1, "Ada", &email, &age, testCreatedAt, 3))
+
+		u, err := repo.GetByID(context.Background(), 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u.ID != 1 || u.Name != "Ada" || *u.Email != email || *u.Age != age || !u.CreatedAt.Equal(testCreatedAt) || u.Version != 3 {
+			t.Errorf("GetByID = %+v", u)
+		}
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		repo, mock := newMockRepository(t, false)
+		mock.ExpectQuery(queryGetUser).WithArgs(999).WillReturnRows(mock.NewRows(versionedUserColumns))
+
+		if _, err := repo.GetByID(context.Background(), 999); !errors.Is(err, ErrNotFound) {
+			t.Errorf("err = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("prepared", func(t *testing.T) {
+		// With the hot statements prepared the query runs by name.
+		repo, mock := newMockRepository(t, true)
+		mock.ExpectQuery("get_user").WithArgs(1).WillReturnRows(
+			mock.NewRows(versionedUserColumns).AddRow(1, "Ada", &email, &age, testCreatedAt, 1))
+
+		if _, err := repo.GetByID(context.Background(), 1); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestSQLList(t *testing.T) {
+	tests := []struct {
+		name          string
+		order         UserOrder
+		limit, offset int
+		query         string
+		args          []any
+	}{
+		{"default", UserOrder{}, 0, 0, queryListUsers, nil},
+		{"default paged", UserOrder{}, 10, 20, queryPageUsers, []any{10, 20}},
+		{"by age", UserOrder{Column: "age"}, 0, 0, selectUsers + " ORDER BY age, id", nil},
+		{"by age descending, paged", UserOrder{Column: "age", Desc: true}, 10, 20,
+			selectUsers + " ORDER BY age DESC, id DESC LIMIT $1 OFFSET $2", []any{10, 20}},
+		{"by id descending", UserOrder{Column: "id", Desc: true}, 0, 0, selectUsers + " ORDER BY id DESC", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock := newMockRepository(t, false)
+			mock.ExpectQuery(tt.query).WithArgs(tt.args...).WillReturnRows(
+				mock.NewRows(userColumns).
+					AddRow(2, "Bob", nil, nil, testCreatedAt).
+					AddRow(1, "Ada", nil, nil, testCreatedAt))
+
+			users, err := repo.List(context.Background(), tt.order, tt.limit, tt.offset)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(users) != 2 || users[0].ID != 2 || users[1].ID != 1 {
+				t.Errorf("List = %+v, want the rows in database order", users)
+			}
+		})
+	}
+}
+
+func TestSQLCreateErrors(t *testing.T) {
+	email := "taken@example.com"
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"unique violation", &pgconn.PgError{Code: sqlStateUniqueViolation}, ErrEmailTaken},
+		{"not null violation", &pgconn.PgError{Code: sqlStateNotNullViolation, ColumnName: "email"}, ErrNullValue},
+		{"foreign key violation", &pgconn.PgError{Code: sqlStateForeignKeyViolation}, ErrInvalidReference},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock := newMockRepository(t, false)
+			mock.ExpectQuery(queryCreateUser).WithArgs("Ada", email, (*int)(nil)).WillReturnError(tt.err)
+
+			_, err := repo.Create(context.Background(), CreateUserRequest{Name: "Ada", Email: email})
+			if !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSQLUpdateIfVersion(t *testing.T) {
+	name := "Renamed"
+	tests := []struct {
+		name   string
+		exists bool
+		want   error
+	}{
+		{"stale version", true, ErrVersionMismatch},
+		{"missing row", false, ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock := newMockRepository(t, false)
+			mock.ExpectQuery(queryUpdateUserIfVersion).
+				WithArgs(&name, (*string)(nil), (*int)(nil), 1, 7).
+				WillReturnRows(mock.NewRows(versionedUserColumns))
+			mock.ExpectQuery(queryUserExists).WithArgs(1).
+				WillReturnRows(mock.NewRows([]string{"exists"}).AddRow(tt.exists))
+
+			_, err := repo.Update(context.Background(), 1, UpdateUserRequest{Name: &name}, 7)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSQLUpdateManyRollsBack(t *testing.T) {
+	name, email := "Renamed", "taken@example.com"
+	repo, mock := newMockRepository(t, false)
+	mock.ExpectBegin()
+	mock.ExpectQuery(queryUpdateUser).
+		WithArgs(&name, (*string)(nil), (*int)(nil), 1).
+		WillReturnRows(mock.NewRows(versionedUserColumns).AddRow(1, name, nil, nil, testCreatedAt, 2))
+	mock.ExpectQuery(queryUpdateUser).
+		WithArgs((*string)(nil), &email, (*int)(nil), 2).
+		WillReturnError(&pgconn.PgError{Code: sqlStateUniqueViolation})
+	mock.ExpectRollback()
+
+	_, err := repo.UpdateMany(context.Background(), []BulkUpdateUserRequest{
+		{ID: 1, UpdateUserRequest: UpdateUserRequest{Name: &name}},
+		{ID: 2, UpdateUserRequest: UpdateUserRequest{Email: &email}},
+	})
+	var bulkErr *BulkUpdateError
+	if !errors.As(err, &bulkErr) || bulkErr.ID != 2 || !errors.Is(err, ErrEmailTaken) {
+		t.Errorf("err = %v, want ErrEmailTaken for user 2", err)
+	}
+}
+
+func TestSQLDeleteMissing(t *testing.T) {
+	repo, mock := newMockRepository(t, false)
+	mock.ExpectQuery(queryDeleteUser).WithArgs(999).WillReturnRows(mock.NewRows([]string{"id"}))
+
+	if err := repo.Delete(context.Background(), 999); !errors.Is(err, ErrNotFound) {
+		t.Errorf("err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestSQLRandomFallsBackToScan(t *testing.T) {
+	// An id drawn from the seeded range that has since been deleted.
+	repo, mock := newMockRepository(t, false)
+	repo.maxID = 10
+	mock.ExpectQuery(queryRandomUserByID).WithArgs(pgxmock.AnyArg()).WillReturnRows(mock.NewRows(userColumns))
+	mock.ExpectQuery(queryRandomUser).WillReturnRows(mock.NewRows(userColumns).AddRow(4, "Dan", nil, nil, testCreatedAt))
+
+	u, err := repo.Random(context.Background(), RandomByID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.ID != 4 {
+		t.Errorf("Random = %+v, want user 4 from the scan", u)
+	}
+}
+
+func TestSQLRandomEmptyTable(t *testing.T) {
+	repo, mock := newMockRepository(t, false)
+	mock.ExpectQuery(queryRandomUser).WillReturnRows(mock.NewRows(userColumns))
+
+	if _, err := repo.Random(context.Background(), RandomScan); !errors.Is(err, ErrNotFound) {
+		t.Errorf("err = %v, want ErrNotFound", err)
+	}
+}
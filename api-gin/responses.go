@@ -0,0 +1,226 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mailru/easyjson"
+	"github.com/mailru/easyjson/jwriter"
+
+	"problem"
+)
+
+// ---------------------------------------------------------------------------
+// Response shapes
+// ---------------------------------------------------------------------------
+
+// Every JSON response is a typed struct rather than a gin.H map: a map is
+// allocated per request, its keys are sorted on every encode, and its values
+// go through interface boxing, which hides most of what the faster encoders
+// (-tags=jsoniter, -tags=sonic,avx) can do with a known struct layout.
+//
+// Field order is the serialized key order.
+
+// ErrorResponse is the problem details envelope (RFC 7807) shared by every
+// Go implementation. The other error shapes embed the same members and add
+// one of their own.
+//
+//easyjson:json
+type ErrorResponse struct {
+	problem.Details
+}
+
+// RetryErrorResponse is the envelope of 429 and 503 overload responses; the
+// Retry-After header carries the same hint.
+type RetryErrorResponse struct {
+	problem.Details
+	RetryAfter int `json:"retry_after"`
+}
+
+// BodyTooLargeResponse reports the MAX_BODY_BYTES limit on 413.
+type BodyTooLargeResponse struct {
+	problem.Details
+	Limit int64 `json:"limit"`
+}
+
+// BulkIndexErrorResponse points at the invalid element of a PUT /users body.
+type BulkIndexErrorResponse struct {
+	problem.Details
+	Index int `json:"index"`
+}
+
+// BulkIDErrorResponse names the user id that failed a PUT /users batch.
+type BulkIDErrorResponse struct {
+	problem.Details
+	ID int `json:"id"`
+}
+
+// PanicResponse is the 500 body written by jsonRecovery.
+type PanicResponse struct {
+	problem.Details
+	RequestID string `json:"request_id"`
+}
+
+// MessageResponse is the GET /json body.
+type MessageResponse struct {
+	Message   string `json:"message"`
+	Framework string `json:"framework"`
+}
+
+// RootResponse is the GET / body.
+type RootResponse struct {
+	Message      string       `json:"message"`
+	Framework    string       `json:"framework"`
+	Runtime      string       `json:"runtime"`
+	Capabilities Capabilities `json:"capabilities"`
+}
+
+// Capabilities describes the instance for harnesses that auto-discover it.
+type Capabilities struct {
+	Routes     []string `json:"routes"`
+	GoVersion  string   `json:"go_version"`
+	GOMAXPROCS int      `json:"gomaxprocs"`
+	DBPoolSize int      `json:"db_pool_size"`
+}
+
+// StatusResponse is the body of the readiness probes.
+type StatusResponse struct {
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// ReplicaLagResponse is the GET /readyz/replica body once the lag is known;
+// LagSeconds is null while the replica has not replayed anything.
+type ReplicaLagResponse struct {
+	Status        string   `json:"status"`
+	LagSeconds    *float64 `json:"lag_seconds"`
+	MaxLagSeconds float64  `json:"max_lag_seconds"`
+}
+
+// SlowResponse is the GET /slow body.
+type SlowResponse struct {
+	SleptMS int `json:"slept_ms"`
+}
+
+// CreatedIDResponse is the POST /users body under return=minimal.
+type CreatedIDResponse struct {
+	ID int `json:"id"`
+}
+
+// ClientIPResponse is the GET /client-ip body. Headers holds the values of
+// the REMOTE_IP_HEADERS present on the request.
+type ClientIPResponse struct {
+	ClientIP string            `json:"client_ip"`
+	RemoteIP string            `json:"remote_ip"`
+	Headers  map[string]string `json:"headers,omitempty"`
+}
+
+// PGOProfileResponse is the POST /debug/pgo body.
+type PGOProfileResponse struct {
+	Path    string `json:"path"`
+	Seconds int    `json:"seconds"`
+	Bytes   int64  `json:"bytes"`
+}
+
+// ---------------------------------------------------------------------------
+// easyjson rendering (EASYJSON=1)
+// ---------------------------------------------------------------------------
+
+// User, Users and ErrorResponse carry easyjson encoders generated into
+// responses_easyjson.go with -no_std_marshalers, so encoding/json, jsoniter
+// and sonic never pick them up through json.Marshaler and the engines stay
+// comparable. easyjson cannot bootstrap package main; regenerate by running
+// it over copies of the three types in a scratch package and renaming the
+// package of the output.
+
+// useEasyJSON routes the hot handlers through the generated encoders; set
+// once by setupRouter.
+var useEasyJSON bool
+
+var jsonContentType = []string{"application/json; charset=utf-8"}
+
+// easyJSONRender is a gin render.Render writing v with its generated,
+// reflection-free encoder.
+type easyJSONRender struct {
+	v easyjson.Marshaler
+}
+
+func (r easyJSONRender) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	var jw jwriter.Writer
+	r.v.MarshalEasyJSON(&jw)
+	if jw.Error != nil {
+		return jw.Error
+	}
+	_, err := jw.DumpTo(w)
+	return err
+}
+
+func (r easyJSONRender) WriteContentType(w http.ResponseWriter) {
+	h := w.Header()
+	if len(h["Content-Type"]) == 0 {
+		h["Content-Type"] = jsonContentType
+	}
+}
+
+// renderJSON writes v with easyjson when enabled, and through c.JSON (the
+// build's JSON engine) otherwise.
+func renderJSON(c *gin.Context, status int, v easyjson.Marshaler) {
+	if useEasyJSON {
+		c.Render(status, easyJSONRender{v})
+		return
+	}
+	c.JSON(status, v)
+}
+
+// newProblem returns the problem details of status for the request of c;
+// detail, when non-empty, is appended to msg.
+func newProblem(c *gin.Context, status int, msg, detail string) problem.Details {
+	return problem.New(status, c.Request.URL.Path, msg, detail)
+}
+
+// errorJSON writes an ErrorResponse. It goes through renderJSON, so
+// EASYJSON=1 covers the error paths as well.
+func errorJSON(c *gin.Context, status int, msg, detail string) {
+	c.Header("Content-Type", problem.ContentType)
+	renderJSON(c, status, ErrorResponse{newProblem(c, status, msg, detail)})
+}
+
+// problemJSON writes v, one of the extended error shapes, through the
+// build's JSON engine. Both writers set the content type first, so the
+// renderers keep it.
+func problemJSON(c *gin.Context, status int, v any) {
+	c.Header("Content-Type", problem.ContentType)
+	c.JSON(status, v)
+}
+
+// ---------------------------------------------------------------------------
+// Pre-marshaled responses
+// ---------------------------------------------------------------------------
+
+// staticJSON is a 200 response encoded once. Like /plaintext, the header
+// values are prebuilt slices assigned straight into the header map.
+type staticJSON struct {
+	body          []byte
+	contentLength []string
+}
+
+// mustStaticJSON encodes v; the values passed in are fixed shapes, so a
+// failure is a programming error.
+func mustStaticJSON(v any) staticJSON {
+	body, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return staticJSON{body: body, contentLength: []string{strconv.Itoa(len(body))}}
+}
+
+func (s staticJSON) write(c *gin.Context) {
+	h := c.Writer.Header()
+	h["Content-Type"] = jsonContentType
+	h["Content-Length"] = s.contentLength
+	c.Writer.WriteHeader(http.StatusOK)
+	c.Writer.Write(s.body)
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"strconv"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ---------------------------------------------------------------------------
+// Transient database error retries (DB_RETRY)
+// ---------------------------------------------------------------------------
+
+// headerDBRetries reports how many times the request's database calls were
+// retried, summed over all of them.
+const headerDBRetries = "X-DB-Retries"
+
+// SQLSTATEs after which the server has rolled the statement back, so
+// running it again is safe.
+const (
+	sqlStateSerializationFailure = "40001"
+	sqlStateDeadlockDetected     = "40P01"
+	sqlStateAdminShutdown        = "57P01"
+)
+
+// retryableErr reports whether err is transient: a serialization failure or
+// deadlock, a connection the server terminated or that was reset, or a
+// request pgx knows never reached the server. Context errors, pool
+// exhaustion and the repository's domain errors are final.
+func retryableErr(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case sqlStateSerializationFailure, sqlStateDeadlockDetected, sqlStateAdminShutdown:
+			return true
+		}
+		// Class 08: connection exception.
+		return len(pgErr.Code) == 5 && pgErr.Code[:2] == "08"
+	}
+	return pgconn.SafeToRetry(err) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// retryUserRepository runs a repository call again, up to attempts extra
+// times, when it fails with a transient error, sleeping a full-jitter
+// exponential backoff (a random delay below backoff, 2×backoff, 4×backoff…)
+// in between, so write-heavy runs under connection churn complete instead
+// of answering 500. It wraps the query timeout, giving each attempt its own
+// deadline, and sits inside the circuit breaker, which sees one outcome per
+// call.
+//
+// A connection reset can hide a write the server already committed; Create
+// then inserts a second row. That is accepted here: the point is measuring
+// throughput under churn, not exactly-once writes.
+type retryUserRepository struct {
+	repo     UserRepository
+	attempts int
+	backoff  time.Duration
+}
+
+func withRetry(repo UserRepository, attempts int, backoff time.Duration) UserRepository {
+	return &retryUserRepository{repo: repo, attempts: attempts, backoff: backoff}
+}
+
+// retried runs fn until it succeeds, fails for good, the retries run out or
+// ctx ends.
+func retried[T any](ctx context.Context, r *retryUserRepository, fn func() (T, error)) (T, error) {
+	v, err := fn()
+	for i := 0; i < r.attempts && retryableErr(err) && r.wait(ctx, i); i++ {
+		v, err = fn()
+	}
+	return v, err
+}
+
+// wait sleeps the backoff before retry i+1 and counts the retry on the
+// request (see dbRetries). It returns false, without counting, if ctx ends
+// first.
+func (r *retryUserRepository) wait(ctx context.Context, i int) bool {
+	t := time.NewTimer(rand.N(r.backoff << i))
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+	}
+	if n, ok := ctx.Value(dbRetriesKey{}).(*atomic.Int32); ok {
+		n.Add(1)
+	}
+	return true
+}
+
+func (r *retryUserRepository) Random(ctx context.Context, mode RandomMode) (User, error) {
+	return retried(ctx, r, func() (User, error) { return r.repo.Random(ctx, mode) })
+}
+
+func (r *retryUserRepository) RandomSampled(ctx context.Context) (User, error) {
+	return retried(ctx, r, func() (User, error) { return r.repo.RandomSampled(ctx) })
+}
+
+func (r *retryUserRepository) RandomN(ctx context.Context, n int, mode RandomMode) ([]User, error) {
+	return retried(ctx, r, func() ([]User, error) { return r.repo.RandomN(ctx, n, mode) })
+}
+
+func (r *retryUserRepository) RandomPinned(ctx context.Context, n int, mode RandomMode) ([]User, error) {
+	return retried(ctx, r, func() ([]User, error) { return r.repo.RandomPinned(ctx, n, mode) })
+}
+
+func (r *retryUserRepository) RandomBatched(ctx context.Context, n int, mode RandomMode) ([]User, error) {
+	return retried(ctx, r, func() ([]User, error) { return r.repo.RandomBatched(ctx, n, mode) })
+}
+
+func (r *retryUserRepository) List(ctx context.Context, order UserOrder, limit, offset int) ([]User, error) {
+	return retried(ctx, r, func() ([]User, error) { return r.repo.List(ctx, order, limit, offset) })
+}
+
+// Stream is only retried while no row has reached fn: after that part of
+// the response is already on the wire.
+func (r *retryUserRepository) Stream(ctx context.Context, order UserOrder, limit, offset int, fn func(User) error) error {
+	var started bool
+	stream := func() error {
+		return r.repo.Stream(ctx, order, limit, offset, func(u User) error {
+			started = true
+			return fn(u)
+		})
+	}
+	err := stream()
+	for i := 0; i < r.attempts && !started && retryableErr(err) && r.wait(ctx, i); i++ {
+		err = stream()
+	}
+	return err
+}
+
+func (r *retryUserRepository) Count(ctx context.Context) (int, error) {
+	return retried(ctx, r, func() (int, error) { return r.repo.Count(ctx) })
+}
+
+func (r *retryUserRepository) Stats(ctx context.Context) (UserStats, error) {
+	return retried(ctx, r, func() (UserStats, error) { return r.repo.Stats(ctx) })
+}
+
+func (r *retryUserRepository) GetByID(ctx context.Context, id int) (User, error) {
+	return retried(ctx, r, func() (User, error) { return r.repo.GetByID(ctx, id) })
+}
+
+func (r *retryUserRepository) Create(ctx context.Context, req CreateUserRequest) (User, error) {
+	return retried(ctx, r, func() (User, error) { return r.repo.Create(ctx, req) })
+}
+
+func (r *retryUserRepository) CreateMinimal(ctx context.Context, req CreateUserRequest) (int, error) {
+	return retried(ctx, r, func() (int, error) { return r.repo.CreateMinimal(ctx, req) })
+}
+
+func (r *retryUserRepository) Update(ctx context.Context, id int, req UpdateUserRequest, version int) (User, error) {
+	return retried(ctx, r, func() (User, error) { return r.repo.Update(ctx, id, req, version) })
+}
+
+func (r *retryUserRepository) UpdateMany(ctx context.Context, reqs []BulkUpdateUserRequest) ([]User, error) {
+	return retried(ctx, r, func() ([]User, error) { return r.repo.UpdateMany(ctx, reqs) })
+}
+
+func (r *retryUserRepository) Replace(ctx context.Context, u User) (User, error) {
+	return retried(ctx, r, func() (User, error) { return r.repo.Replace(ctx, u) })
+}
+
+func (r *retryUserRepository) Delete(ctx context.Context, id int) error {
+	_, err := retried(ctx, r, func() (struct{}, error) { return struct{}{}, r.repo.Delete(ctx, id) })
+	return err
+}
+
+// dbRetriesKey carries the request's retry counter in its context.
+type dbRetriesKey struct{}
+
+// dbRetries counts the retries retryUserRepository makes for the request
+// and reports them in X-DB-Retries (0 included) when the headers are
+// committed; the queries have finished by then, except for a streamed
+// response, which is no longer retried. Installed on the database routes
+// with DB_RETRY=1.
+func dbRetries() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		w := &dbRetriesWriter{ResponseWriter: c.Writer}
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), dbRetriesKey{}, &w.n))
+		c.Writer = w
+		c.Next()
+		w.stamp()
+	}
+}
+
+// dbRetriesWriter stamps X-DB-Retries like responseTimeWriter stamps
+// X-Response-Time.
+type dbRetriesWriter struct {
+	gin.ResponseWriter
+	n       atomic.Int32
+	stamped bool
+}
+
+func (w *dbRetriesWriter) stamp() {
+	if w.stamped || w.ResponseWriter.Written() {
+		return
+	}
+	w.stamped = true
+	w.Header().Set(headerDBRetries, strconv.Itoa(int(w.n.Load())))
+}
+
+func (w *dbRetriesWriter) WriteHeaderNow() {
+	w.stamp()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *dbRetriesWriter) Write(b []byte) (int, error) {
+	w.stamp()
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *dbRetriesWriter) WriteString(s string) (int, error) {
+	w.stamp()
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *dbRetriesWriter) Flush() {
+	w.stamp()
+	w.ResponseWriter.Flush()
+}
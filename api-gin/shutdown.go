@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ---------------------------------------------------------------------------
+// Graceful shutdown
+// ---------------------------------------------------------------------------
+
+// requestTracker counts the public API's requests in flight and completed,
+// so shutdown can report how many it drained and how many it had to cut off
+// at DRAIN_TIMEOUT.
+type requestTracker struct {
+	inFlight  atomic.Int64
+	completed atomic.Uint64
+}
+
+// track is the outermost public middleware. jsonRecovery runs inside it, so
+// c.Next returns even when the handler panics.
+func (t *requestTracker) track() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		t.inFlight.Add(1)
+		c.Next()
+		t.inFlight.Add(-1)
+		t.completed.Add(1)
+	}
+}
+
+// closeIdleConns closes the connections idle in db and returns how many, so
+// Postgres gets their slots back before the drain instead of after it; busy
+// connections are closed by db.Close once their requests finish. Closing
+// before Release makes the pool destroy them through its usual hooks.
+func closeIdleConns(ctx context.Context, db *pgxpool.Pool) int {
+	conns := db.AcquireAllIdle(ctx)
+	for _, conn := range conns {
+		_ = conn.Conn().Close(ctx)
+		conn.Release()
+	}
+	return len(conns)
+}
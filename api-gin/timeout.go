@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Per-query timeout (DB_QUERY_TIMEOUT)
+// ---------------------------------------------------------------------------
+
+// timeoutUserRepository gives every repository call its own context deadline,
+// so one slow query fails fast with 504 instead of holding the request until
+// the 10s server write timeout and skewing tail latency. The deadline is per
+// call, not per request: /queries?conn=per-row gets it for each of its N
+// queries. A deadline hit while the pool is saturated still surfaces as
+// ErrPoolExhausted.
+type timeoutUserRepository struct {
+	repo    UserRepository
+	timeout time.Duration
+}
+
+func withQueryTimeout(repo UserRepository, timeout time.Duration) UserRepository {
+	return &timeoutUserRepository{repo: repo, timeout: timeout}
+}
+
+func (r *timeoutUserRepository) Random(ctx context.Context, mode RandomMode) (User, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.repo.Random(ctx, mode)
+}
+
+func (r *timeoutUserRepository) RandomSampled(ctx context.Context) (User, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.repo.RandomSampled(ctx)
+}
+
+func (r *timeoutUserRepository) RandomN(ctx context.Context, n int, mode RandomMode) ([]User, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.repo.RandomN(ctx, n, mode)
+}
+
+func (r *timeoutUserRepository) RandomPinned(ctx context.Context, n int, mode RandomMode) ([]User, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.repo.RandomPinned(ctx, n, mode)
+}
+
+func (r *timeoutUserRepository) RandomBatched(ctx context.Context, n int, mode RandomMode) ([]User, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.repo.RandomBatched(ctx, n, mode)
+}
+
+func (r *timeoutUserRepository) List(ctx context.Context, order UserOrder, limit, offset int) ([]User, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.repo.List(ctx, order, limit, offset)
+}
+
+// Stream is bounded as a whole: the deadline also covers writing the rows
+// to the client through fn.
+func (r *timeoutUserRepository) Stream(ctx context.Context, order UserOrder, limit, offset int, fn func(User) error) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.repo.Stream(ctx, order, limit, offset, fn)
+}
+
+func (r *timeoutUserRepository) Count(ctx context.Context) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.repo.Count(ctx)
+}
+
+func (r *timeoutUserRepository) Stats(ctx context.Context) (UserStats, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.repo.Stats(ctx)
+}
+
+func (r *timeoutUserRepository) GetByID(ctx context.Context, id int) (User, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.repo.GetByID(ctx, id)
+}
+
+func (r *timeoutUserRepository) Create(ctx context.Context, req CreateUserRequest) (User, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.repo.Create(ctx, req)
+}
+
+func (r *timeoutUserRepository) CreateMinimal(ctx context.Context, req CreateUserRequest) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.repo.CreateMinimal(ctx, req)
+}
+
+func (r *timeoutUserRepository) Update(ctx context.Context, id int, req UpdateUserRequest, version int) (User, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.repo.Update(ctx, id, req, version)
+}
+
+func (r *timeoutUserRepository) UpdateMany(ctx context.Context, reqs []BulkUpdateUserRequest) ([]User, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.repo.UpdateMany(ctx, reqs)
+}
+
+func (r *timeoutUserRepository) Replace(ctx context.Context, u User) (User, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.repo.Replace(ctx, u)
+}
+
+func (r *timeoutUserRepository) Delete(ctx context.Context, id int) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.repo.Delete(ctx, id)
+}
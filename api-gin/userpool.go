@@ -0,0 +1,52 @@
+package main
+
+import "sync"
+
+// ---------------------------------------------------------------------------
+// Pooled user slices
+// ---------------------------------------------------------------------------
+
+// The /queries variants build a fresh []User per request, up to
+// MAX_QUERIES_COUNT rows. Their backing arrays are recycled through
+// userSlicePool instead: the repositories take them from getUsers, and
+// handleQueries hands them back with putUsers once the response is written.
+// A slice that is never returned (an error path, a gRPC caller) is simply
+// collected, so callers other than handleQueries need no changes.
+
+// maxPooledUsers bounds the capacity kept in the pool, so one large listing
+// does not pin its array for the life of the process.
+const maxPooledUsers = defaultMaxQueriesCount
+
+var userSlicePool = sync.Pool{
+	New: func() any {
+		s := make([]User, 0, 20)
+		return &s
+	},
+}
+
+// getUsers returns an empty slice with room for at least n users.
+func getUsers(n int) []User {
+	if n > maxPooledUsers {
+		return make([]User, 0, n)
+	}
+	sp := userSlicePool.Get().(*[]User)
+	if cap(*sp) < n {
+		userSlicePool.Put(sp)
+		return make([]User, 0, n)
+	}
+	return (*sp)[:0]
+}
+
+// putUsers recycles s. The caller must not touch s afterwards; the response
+// it was rendered into has to be fully written.
+func putUsers(s []User) {
+	if cap(s) == 0 || cap(s) > maxPooledUsers {
+		return
+	}
+	// Drop the Email/Age pointers of every slot ever used, not just the
+	// current length, so pooled arrays do not keep old rows alive.
+	s = s[:cap(s)]
+	clear(s)
+	s = s[:0]
+	userSlicePool.Put(&s)
+}
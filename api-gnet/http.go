@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+)
+
+// ---------------------------------------------------------------------------
+// Minimal HTTP/1.1
+// ---------------------------------------------------------------------------
+
+// Just enough of RFC 9112 for a load generator: request line, headers,
+// Content-Length bodies (read and discarded), keep-alive and pipelining.
+// Chunked request bodies, upgrades and the like are rejected.
+
+const (
+	maxHeaderBytes = 8 << 10
+	maxBodyBytes   = 1 << 20
+)
+
+var (
+	errBadRequest     = errors.New("bad request")
+	errHeaderTooBig   = errors.New("request header too large")
+	errBodyTooLarge   = errors.New("request body too large")
+	errNotImplemented = errors.New("transfer-encoding not supported")
+)
+
+var (
+	crlfcrlf = []byte("\r\n\r\n")
+	http11   = []byte("HTTP/1.1")
+	http10   = []byte("HTTP/1.0")
+)
+
+// request is a parsed request head. Its slices alias the connection's
+// inbound buffer and are only valid until that is discarded.
+type request struct {
+	method    []byte
+	path      []byte
+	keepAlive bool
+}
+
+// parseRequest parses one request from the front of buf and reports how
+// many bytes it occupies; n == 0 with a nil error means buf does not yet
+// hold a whole request.
+func parseRequest(buf []byte) (req request, n int, err error) {
+	end := bytes.Index(buf, crlfcrlf)
+	if end < 0 {
+		if len(buf) > maxHeaderBytes {
+			return req, 0, errHeaderTooBig
+		}
+		return req, 0, nil
+	}
+	if end > maxHeaderBytes {
+		return req, 0, errHeaderTooBig
+	}
+	head := buf[:end]
+
+	line, rest, _ := bytes.Cut(head, []byte("\r\n"))
+	method, line, ok1 := bytes.Cut(line, []byte(" "))
+	target, proto, ok2 := bytes.Cut(line, []byte(" "))
+	if !ok1 || !ok2 || len(method) == 0 || len(target) == 0 || target[0] != '/' {
+		return req, 0, errBadRequest
+	}
+	switch {
+	case bytes.Equal(proto, http11):
+		req.keepAlive = true
+	case bytes.Equal(proto, http10):
+		req.keepAlive = false
+	default:
+		return req, 0, errBadRequest
+	}
+	req.method = method
+	req.path, _, _ = bytes.Cut(target, []byte("?"))
+
+	contentLength := 0
+	for len(rest) > 0 {
+		var field []byte
+		field, rest, _ = bytes.Cut(rest, []byte("\r\n"))
+		name, value, ok := bytes.Cut(field, []byte(":"))
+		if !ok {
+			return req, 0, errBadRequest
+		}
+		value = bytes.TrimSpace(value)
+		switch {
+		case bytes.EqualFold(name, []byte("Content-Length")):
+			cl, err := strconv.Atoi(string(value))
+			if err != nil || cl < 0 {
+				return req, 0, errBadRequest
+			}
+			if cl > maxBodyBytes {
+				return req, 0, errBodyTooLarge
+			}
+			contentLength = cl
+		case bytes.EqualFold(name, []byte("Transfer-Encoding")):
+			return req, 0, errNotImplemented
+		case bytes.EqualFold(name, []byte("Connection")):
+			if bytes.EqualFold(value, []byte("close")) {
+				req.keepAlive = false
+			} else if bytes.EqualFold(value, []byte("keep-alive")) {
+				req.keepAlive = true
+			}
+		}
+	}
+
+	n = end + len(crlfcrlf) + contentLength
+	if len(buf) < n {
+		return req, 0, nil
+	}
+	return req, n, nil
+}
+
+// appendResponse appends a complete response; the Date header value comes
+// from the once-a-second cache.
+func appendResponse(b []byte, status, contentType string, extra string, body []byte, keepAlive bool) []byte {
+	b = append(b, "HTTP/1.1 "...)
+	b = append(b, status...)
+	b = append(b, "\r\nServer: gnet\r\nDate: "...)
+	b = append(b, *currentDate.Load()...)
+	b = append(b, "\r\nContent-Type: "...)
+	b = append(b, contentType...)
+	b = append(b, "\r\nContent-Length: "...)
+	b = strconv.AppendInt(b, int64(len(body)), 10)
+	b = append(b, "\r\n"...)
+	b = append(b, extra...)
+	if !keepAlive {
+		b = append(b, "Connection: close\r\n"...)
+	}
+	b = append(b, "\r\n"...)
+	return append(b, body...)
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/panjf2000/gnet/v2"
+	_ "go.uber.org/automaxprocs" // GOMAXPROCS from the container CPU quota
+
+	"problem"
+	"tuning"
+)
+
+// Experimental: gnet's epoll/kqueue event loops with a hand-written
+// HTTP/1.1 layer, serving only the DB-free /json and /plaintext routes.
+// There is no database here; the point is the ceiling of a non-net/http
+// design in Go.
+
+// ---------------------------------------------------------------------------
+// Responses
+// ---------------------------------------------------------------------------
+
+const (
+	contentTypeJSON    = "application/json; charset=utf-8"
+	contentTypePlain   = "text/plain; charset=utf-8"
+	contentTypeProblem = problem.ContentType
+)
+
+// Bodies match the other implementations byte for byte (encoding/json
+// sorts map keys, hence "framework" first).
+var (
+	jsonBody      = []byte(`{"framework":"gnet","message":"Hello, World!"}`)
+	plaintextBody = []byte("Hello, World!")
+)
+
+// Parse errors have no usable request path, so their problem details carry
+// no instance and can be encoded once.
+var (
+	badRequestBody     = problem.New(http.StatusBadRequest, "", "Bad request", "").JSON()
+	headerTooBigBody   = problem.New(http.StatusRequestHeaderFieldsTooLarge, "", "Request header too large", "").JSON()
+	bodyTooLargeBody   = problem.New(http.StatusRequestEntityTooLarge, "", "Request body too large", "").JSON()
+	notImplementedBody = problem.New(http.StatusNotImplemented, "", "Transfer-Encoding not supported", "").JSON()
+)
+
+// currentDate holds the preformatted Date header value; OnTick refreshes it
+// every second so no request formats a time.
+var currentDate atomic.Pointer[[]byte]
+
+func updateDate() {
+	d := []byte(time.Now().UTC().Format(http.TimeFormat))
+	currentDate.Store(&d)
+}
+
+// route appends the response for one parsed request.
+func route(out []byte, req request) []byte {
+	var body []byte
+	contentType := contentTypeJSON
+	switch string(req.path) {
+	case "/json":
+		body = jsonBody
+	case "/plaintext":
+		body, contentType = plaintextBody, contentTypePlain
+	default:
+		body = problem.New(http.StatusNotFound, string(req.path), "Not found", "").JSON()
+		return appendResponse(out, "404 Not Found", contentTypeProblem, "", body, req.keepAlive)
+	}
+	if string(req.method) != http.MethodGet {
+		body = problem.New(http.StatusMethodNotAllowed, string(req.path), "Method not allowed", "").JSON()
+		return appendResponse(out, "405 Method Not Allowed", contentTypeProblem, "Allow: GET\r\n", body, req.keepAlive)
+	}
+	return appendResponse(out, "200 OK", contentType, "", body, req.keepAlive)
+}
+
+// errorResponse maps a parse error to its status line and body.
+func errorResponse(err error) (string, []byte) {
+	switch err {
+	case errHeaderTooBig:
+		return "431 Request Header Fields Too Large", headerTooBigBody
+	case errBodyTooLarge:
+		return "413 Content Too Large", bodyTooLargeBody
+	case errNotImplemented:
+		return "501 Not Implemented", notImplementedBody
+	default:
+		return "400 Bad Request", badRequestBody
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Event handler
+// ---------------------------------------------------------------------------
+
+type httpServer struct {
+	gnet.BuiltinEventEngine
+
+	addr string
+	eng  gnet.Engine
+}
+
+// connState is the per-connection output buffer, reused across reads.
+type connState struct {
+	out []byte
+}
+
+func (s *httpServer) OnBoot(eng gnet.Engine) gnet.Action {
+	s.eng = eng
+	log.Printf("gnet API listening on http://%s", s.addr)
+	return gnet.None
+}
+
+func (s *httpServer) OnOpen(c gnet.Conn) ([]byte, gnet.Action) {
+	c.SetContext(&connState{out: make([]byte, 0, 4096)})
+	return nil, gnet.None
+}
+
+func (s *httpServer) OnTick() (time.Duration, gnet.Action) {
+	updateDate()
+	return time.Second, gnet.None
+}
+
+// OnTraffic answers every complete request in the inbound buffer in one
+// write (pipelining), leaving a partial trailing request for the next
+// event.
+func (s *httpServer) OnTraffic(c gnet.Conn) gnet.Action {
+	st := c.Context().(*connState)
+	out := st.out[:0]
+
+	buf, _ := c.Peek(-1)
+	consumed := 0
+	action := gnet.None
+	for consumed < len(buf) {
+		req, n, err := parseRequest(buf[consumed:])
+		if err != nil {
+			status, body := errorResponse(err)
+			out = appendResponse(out, status, contentTypeProblem, "", body, false)
+			action = gnet.Close
+			consumed = len(buf)
+			break
+		}
+		if n == 0 {
+			break
+		}
+		out = route(out, req)
+		consumed += n
+		if !req.keepAlive {
+			action = gnet.Close
+			consumed = len(buf)
+			break
+		}
+	}
+	// Discard(0) would drop the whole buffer, partial request included.
+	if consumed > 0 {
+		_, _ = c.Discard(consumed)
+	}
+
+	if len(out) > 0 {
+		if _, err := c.Write(out); err != nil {
+			return gnet.Close
+		}
+	}
+	st.out = out
+	return action
+}
+
+// ---------------------------------------------------------------------------
+// Entry point
+// ---------------------------------------------------------------------------
+
+func main() {
+	if err := tuning.Apply(); err != nil {
+		log.Fatalf("runtime tuning: %v", err)
+	}
+	tuning.LogSettings()
+
+	// The HTTP/1.1 parser reads raw connections straight off the event
+	// loop; there is no TLS layer to put under it.
+	if os.Getenv("TLS_CERT") != "" {
+		log.Fatal("tls: TLS_CERT is not supported by the gnet implementation")
+	}
+
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "3017"
+	}
+
+	updateDate()
+	s := &httpServer{addr: fmt.Sprintf("0.0.0.0:%s", port)}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- gnet.Run(s, "tcp://"+s.addr,
+			gnet.WithMulticore(true),
+			gnet.WithReusePort(true),
+			gnet.WithTicker(true),
+			gnet.WithTCPKeepAlive(60*time.Second),
+		)
+	}()
+
+	// Graceful shutdown: wait for SIGINT or SIGTERM.
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	select {
+	case err := <-errCh:
+		log.Fatalf("server error: %v", err)
+	case <-quit:
+	}
+
+	log.Println("shutting down server...")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := s.eng.Stop(ctx); err != nil {
+		log.Fatalf("forced shutdown: %v", err)
+	}
+
+	log.Println("server stopped")
+}
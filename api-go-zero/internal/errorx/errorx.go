@@ -0,0 +1,44 @@
+package errorx
+
+import (
+	"errors"
+	"net/http"
+
+	"problem"
+)
+
+// CodeError is an error that knows its HTTP status; Write renders it as the
+// problem details envelope shared by every Go implementation.
+type CodeError struct {
+	Status int
+	Msg    string
+	Detail string
+}
+
+func (e *CodeError) Error() string {
+	if e.Detail != "" {
+		return e.Msg + ": " + e.Detail
+	}
+	return e.Msg
+}
+
+func New(status int, msg string) *CodeError {
+	return &CodeError{Status: status, Msg: msg}
+}
+
+// BadBody wraps a request parsing error.
+func BadBody(err error) *CodeError {
+	return &CodeError{Status: http.StatusBadRequest, Msg: "Invalid JSON body", Detail: err.Error()}
+}
+
+// Write sends err as a problem details response to the request r: a
+// *CodeError with its status and message, anything else as a bare 500.
+// Handlers use it instead of httpx.ErrorCtx, which always answers with
+// application/json.
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	var e *CodeError
+	if !errors.As(err, &e) {
+		e = New(http.StatusInternalServerError, "Internal server error")
+	}
+	problem.Write(w, problem.New(e.Status, r.URL.Path, e.Msg, e.Detail))
+}
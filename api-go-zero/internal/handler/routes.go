@@ -0,0 +1,25 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/zeromicro/go-zero/rest"
+
+	"api-go-zero/internal/svc"
+)
+
+func RegisterHandlers(server *rest.Server, serverCtx *svc.ServiceContext) {
+	server.AddRoutes(
+		[]rest.Route{
+			{Method: http.MethodGet, Path: "/", Handler: RootHandler()},
+			{Method: http.MethodGet, Path: "/json", Handler: JsonHandler()},
+			{Method: http.MethodGet, Path: "/db", Handler: DbHandler(serverCtx)},
+			{Method: http.MethodGet, Path: "/queries", Handler: QueriesHandler(serverCtx)},
+			{Method: http.MethodGet, Path: "/users", Handler: GetUsersHandler(serverCtx)},
+			{Method: http.MethodGet, Path: "/users/:id", Handler: GetUserHandler(serverCtx)},
+			{Method: http.MethodPost, Path: "/users", Handler: CreateUserHandler(serverCtx)},
+			{Method: http.MethodPut, Path: "/users/:id", Handler: UpdateUserHandler(serverCtx)},
+			{Method: http.MethodDelete, Path: "/users/:id", Handler: DeleteUserHandler(serverCtx)},
+		},
+	)
+}
@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/zeromicro/go-zero/rest/httpx"
+
+	"api-go-zero/internal/errorx"
+	"api-go-zero/internal/logic"
+	"api-go-zero/internal/svc"
+	"api-go-zero/internal/types"
+)
+
+// Static bodies for the DB-free routes.
+var (
+	rootBody = map[string]string{"message": "Go-zero API", "framework": "go-zero", "runtime": "go"}
+	jsonBody = map[string]string{"message": "Hello, World!", "framework": "go-zero"}
+)
+
+func RootHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		httpx.OkJsonCtx(r.Context(), w, rootBody)
+	}
+}
+
+func JsonHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		httpx.OkJsonCtx(r.Context(), w, jsonBody)
+	}
+}
+
+func DbHandler(svcCtx *svc.ServiceContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		l := logic.NewUserLogic(r.Context(), svcCtx)
+		resp, err := l.Db()
+		if err != nil {
+			errorx.Write(w, r, err)
+			return
+		}
+		httpx.OkJsonCtx(r.Context(), w, resp)
+	}
+}
+
+func QueriesHandler(svcCtx *svc.ServiceContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req types.CountRequest
+		if err := httpx.Parse(r, &req); err != nil {
+			errorx.Write(w, r, errorx.BadBody(err))
+			return
+		}
+
+		l := logic.NewUserLogic(r.Context(), svcCtx)
+		resp, err := l.Queries(&req)
+		if err != nil {
+			errorx.Write(w, r, err)
+			return
+		}
+		httpx.OkJsonCtx(r.Context(), w, resp)
+	}
+}
+
+func GetUsersHandler(svcCtx *svc.ServiceContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req types.ListUsersRequest
+		if err := httpx.Parse(r, &req); err != nil {
+			errorx.Write(w, r, errorx.BadBody(err))
+			return
+		}
+
+		l := logic.NewUserLogic(r.Context(), svcCtx)
+		resp, err := l.GetUsers(&req)
+		if err != nil {
+			errorx.Write(w, r, err)
+			return
+		}
+		httpx.OkJsonCtx(r.Context(), w, resp)
+	}
+}
+
+func GetUserHandler(svcCtx *svc.ServiceContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req types.UserPathRequest
+		if err := httpx.Parse(r, &req); err != nil {
+			errorx.Write(w, r, errorx.BadBody(err))
+			return
+		}
+
+		l := logic.NewUserLogic(r.Context(), svcCtx)
+		resp, err := l.GetUser(&req)
+		if err != nil {
+			errorx.Write(w, r, err)
+			return
+		}
+		httpx.OkJsonCtx(r.Context(), w, resp)
+	}
+}
+
+func CreateUserHandler(svcCtx *svc.ServiceContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req types.CreateUserRequest
+		if err := httpx.Parse(r, &req); err != nil {
+			errorx.Write(w, r, errorx.BadBody(err))
+			return
+		}
+
+		l := logic.NewUserLogic(r.Context(), svcCtx)
+		resp, err := l.CreateUser(&req)
+		if err != nil {
+			errorx.Write(w, r, err)
+			return
+		}
+		httpx.WriteJsonCtx(r.Context(), w, http.StatusCreated, resp)
+	}
+}
+
+func UpdateUserHandler(svcCtx *svc.ServiceContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req types.UpdateUserRequest
+		if err := httpx.Parse(r, &req); err != nil {
+			errorx.Write(w, r, errorx.BadBody(err))
+			return
+		}
+
+		l := logic.NewUserLogic(r.Context(), svcCtx)
+		resp, err := l.UpdateUser(&req)
+		if err != nil {
+			errorx.Write(w, r, err)
+			return
+		}
+		httpx.OkJsonCtx(r.Context(), w, resp)
+	}
+}
+
+func DeleteUserHandler(svcCtx *svc.ServiceContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req types.UserPathRequest
+		if err := httpx.Parse(r, &req); err != nil {
+			errorx.Write(w, r, errorx.BadBody(err))
+			return
+		}
+
+		l := logic.NewUserLogic(r.Context(), svcCtx)
+		if err := l.DeleteUser(&req); err != nil {
+			errorx.Write(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
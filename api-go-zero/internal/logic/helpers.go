@@ -0,0 +1,69 @@
+package logic
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"api-go-zero/internal/errorx"
+	"api-go-zero/internal/types"
+)
+
+// parseCount clamps the ?count query parameter to [1, 500], defaulting to 1.
+func parseCount(raw string) int {
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 1 {
+		return 1
+	}
+	return min(n, 500)
+}
+
+// parseID converts a URL parameter to a positive integer.
+func parseID(raw string) (int, bool) {
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 1 {
+		return 0, false
+	}
+	return n, true
+}
+
+// scanUser reads a single User from any *sql.Row / *sql.Rows via the scan func.
+func scanUser(scan func(...any) error) (types.User, error) {
+	var u types.User
+	err := scan(&u.Id, &u.Name, &u.Email, &u.Age, &u.CreatedAt)
+	return u, err
+}
+
+// collectUsers drains rows into a slice; sizeHint preallocates it.
+func collectUsers(rows *sql.Rows, sizeHint int) ([]types.User, error) {
+	defer rows.Close()
+	users := make([]types.User, 0, sizeHint)
+	for rows.Next() {
+		user, err := scanUser(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+// isUniqueViolation reports a PostgreSQL unique_violation (SQLSTATE 23505).
+func isUniqueViolation(err error) bool {
+	type hasSQLState interface{ SQLState() string }
+	e, ok := err.(hasSQLState)
+	return ok && e.SQLState() == "23505"
+}
+
+// dbError maps a query error to a CodeError: sql.ErrNoRows is notFound
+// (404), a duplicate email 409, anything else 500 with the driver detail.
+func dbError(err error, notFound string) error {
+	switch {
+	case err == sql.ErrNoRows:
+		return errorx.New(http.StatusNotFound, notFound)
+	case isUniqueViolation(err):
+		return errorx.New(http.StatusConflict, "Email already in use")
+	default:
+		return &errorx.CodeError{Status: http.StatusInternalServerError, Msg: "Database error", Detail: err.Error()}
+	}
+}
@@ -0,0 +1,190 @@
+package logic
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/zeromicro/go-zero/core/logx"
+
+	"api-go-zero/internal/errorx"
+	"api-go-zero/internal/svc"
+	"api-go-zero/internal/types"
+)
+
+const (
+	queryRandomUser  = `SELECT id, name, email, age, created_at FROM users ORDER BY RANDOM() LIMIT 1`
+	queryRandomUsers = `SELECT id, name, email, age, created_at FROM users ORDER BY RANDOM() LIMIT $1`
+	queryListUsers   = `SELECT id, name, email, age, created_at FROM users ORDER BY id`
+	queryPageUsers   = `SELECT id, name, email, age, created_at FROM users ORDER BY id LIMIT $1 OFFSET $2`
+	queryCountUsers  = `SELECT COUNT(*)::int FROM users`
+	queryGetUser     = `SELECT id, name, email, age, created_at FROM users WHERE id = $1`
+	queryCreateUser  = `
+		INSERT INTO users (name, email, age)
+		VALUES ($1, $2, $3)
+		RETURNING id, name, email, age, created_at`
+	// COALESCE updates only the provided fields, the same SQL as api-gin.
+	queryUpdateUser = `
+		UPDATE users
+		SET name  = COALESCE($1, name),
+		    email = COALESCE($2, email),
+		    age   = COALESCE($3, age)
+		WHERE id = $4
+		RETURNING id, name, email, age, created_at`
+	queryDeleteUser = `DELETE FROM users WHERE id = $1 RETURNING id`
+)
+
+// UserLogic holds every /users, /db and /queries operation; goctl would
+// emit one type per handler, grouped here since they share the helpers.
+type UserLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewUserLogic(ctx context.Context, svcCtx *svc.ServiceContext) *UserLogic {
+	return &UserLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// Db returns a single random user.
+func (l *UserLogic) Db() (*types.User, error) {
+	user, err := scanUser(l.svcCtx.DB.QueryRowContext(l.ctx, queryRandomUser).Scan)
+	if err != nil {
+		return nil, dbError(err, "No users found")
+	}
+	return &user, nil
+}
+
+// Queries returns ?count random users (1-500, default 1) in one query.
+func (l *UserLogic) Queries(req *types.CountRequest) ([]types.User, error) {
+	count := parseCount(req.Count)
+
+	rows, err := l.svcCtx.DB.QueryContext(l.ctx, queryRandomUsers, count)
+	if err != nil {
+		return nil, dbError(err, "No users found")
+	}
+	users, err := collectUsers(rows, count)
+	if err != nil {
+		return nil, dbError(err, "No users found")
+	}
+	return users, nil
+}
+
+// GetUsers returns every user ordered by id, or a PaginatedUsers page when
+// ?limit (1-100) is given.
+func (l *UserLogic) GetUsers(req *types.ListUsersRequest) (any, error) {
+	db := l.svcCtx.DB
+
+	if req.Limit == "" {
+		rows, err := db.QueryContext(l.ctx, queryListUsers)
+		if err != nil {
+			return nil, dbError(err, "No users found")
+		}
+		users, err := collectUsers(rows, 0)
+		if err != nil {
+			return nil, dbError(err, "No users found")
+		}
+		return users, nil
+	}
+
+	limit := 20
+	if n, err := strconv.Atoi(req.Limit); err == nil {
+		limit = n
+	}
+	limit = min(max(limit, 1), 100)
+	offset := 0
+	if n, err := strconv.Atoi(req.Offset); err == nil && n > 0 {
+		offset = n
+	}
+
+	// Run COUNT and the paginated SELECT concurrently.
+	type countResult struct {
+		total int
+		err   error
+	}
+	countCh := make(chan countResult, 1)
+	go func() {
+		var total int
+		err := db.QueryRowContext(l.ctx, queryCountUsers).Scan(&total)
+		countCh <- countResult{total, err}
+	}()
+
+	var users []types.User
+	rows, err := db.QueryContext(l.ctx, queryPageUsers, limit, offset)
+	if err == nil {
+		users, err = collectUsers(rows, limit)
+	}
+	cr := <-countCh
+	if cr.err != nil {
+		return nil, dbError(cr.err, "No users found")
+	}
+	if err != nil {
+		return nil, dbError(err, "No users found")
+	}
+
+	return &types.PaginatedUsers{Data: users, Total: cr.total, Limit: limit, Offset: offset}, nil
+}
+
+// GetUser returns one user by id.
+func (l *UserLogic) GetUser(req *types.UserPathRequest) (*types.User, error) {
+	id, ok := parseID(req.Id)
+	if !ok {
+		return nil, errorx.New(http.StatusBadRequest, "Invalid user ID")
+	}
+
+	user, err := scanUser(l.svcCtx.DB.QueryRowContext(l.ctx, queryGetUser, id).Scan)
+	if err != nil {
+		return nil, dbError(err, "User not found")
+	}
+	return &user, nil
+}
+
+// CreateUser inserts a user; a duplicate email is a 409.
+func (l *UserLogic) CreateUser(req *types.CreateUserRequest) (*types.User, error) {
+	if req.Name == "" || req.Email == "" {
+		return nil, errorx.New(http.StatusBadRequest, "name and email are required")
+	}
+
+	row := l.svcCtx.DB.QueryRowContext(l.ctx, queryCreateUser, req.Name, req.Email, req.Age)
+	user, err := scanUser(row.Scan)
+	if err != nil {
+		return nil, dbError(err, "User not found")
+	}
+	return &user, nil
+}
+
+// UpdateUser applies the provided fields to an existing user.
+func (l *UserLogic) UpdateUser(req *types.UpdateUserRequest) (*types.User, error) {
+	id, ok := parseID(req.Id)
+	if !ok {
+		return nil, errorx.New(http.StatusBadRequest, "Invalid user ID")
+	}
+	if req.Name == nil && req.Email == nil && req.Age == nil {
+		return nil, errorx.New(http.StatusBadRequest, "At least one field (name, email, age) is required")
+	}
+
+	row := l.svcCtx.DB.QueryRowContext(l.ctx, queryUpdateUser, req.Name, req.Email, req.Age, id)
+	user, err := scanUser(row.Scan)
+	if err != nil {
+		return nil, dbError(err, "User not found")
+	}
+	return &user, nil
+}
+
+// DeleteUser removes a user by id.
+func (l *UserLogic) DeleteUser(req *types.UserPathRequest) error {
+	id, ok := parseID(req.Id)
+	if !ok {
+		return errorx.New(http.StatusBadRequest, "Invalid user ID")
+	}
+
+	var deletedID int
+	if err := l.svcCtx.DB.QueryRowContext(l.ctx, queryDeleteUser, id).Scan(&deletedID); err != nil {
+		return dbError(err, "User not found")
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package svc
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	_ "github.com/lib/pq"
+
+	"api-go-zero/internal/config"
+)
+
+type ServiceContext struct {
+	Config config.Config
+	DB     *sql.DB
+}
+
+func NewServiceContext(c config.Config) *ServiceContext {
+	return &ServiceContext{
+		Config: c,
+		DB:     setupDB(c.DataSource),
+	}
+}
+
+func setupDB(dsn string) *sql.DB {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		log.Fatalf("failed to open database: %v", err)
+	}
+
+	// Connection pool tuning — identical to api-gin (max: 10).
+	db.SetMaxOpenConns(10)
+	db.SetMaxIdleConns(10)
+	db.SetConnMaxLifetime(0)
+	db.SetConnMaxIdleTime(30 * time.Second)
+
+	if err := waitForDB(db); err != nil {
+		log.Fatalf("failed to connect to database: %v", err)
+	}
+
+	log.Println("database connection established")
+	return db
+}
+
+// waitForDB pings db until it answers, backing off exponentially from 100ms
+// to 5s between attempts, for up to DB_STARTUP_TIMEOUT (default 60s): under
+// docker compose the API can come up before Postgres accepts connections,
+// and exiting then would abort the whole benchmark run.
+func waitForDB(db *sql.DB) error {
+	timeout := 60 * time.Second
+	if d, err := time.ParseDuration(os.Getenv("DB_STARTUP_TIMEOUT")); err == nil && d > 0 {
+		timeout = d
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	backoff := 100 * time.Millisecond
+	for attempt := 1; ; attempt++ {
+		pingCtx, cancelPing := context.WithTimeout(ctx, 5*time.Second)
+		err := db.PingContext(pingCtx)
+		cancelPing()
+		if err == nil {
+			return nil
+		}
+		log.Printf("database not ready (attempt %d): %v; retrying in %s", attempt, err, backoff)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("gave up after %s: %w", timeout, err)
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, 5*time.Second)
+	}
+}
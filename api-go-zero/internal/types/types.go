@@ -0,0 +1,49 @@
+package types
+
+import "time"
+
+type User struct {
+	Id        int       `json:"id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Age       *int      `json:"age"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PaginatedUsers is the response shape when ?limit is provided.
+type PaginatedUsers struct {
+	Data   []User `json:"data"`
+	Total  int    `json:"total"`
+	Limit  int    `json:"limit"`
+	Offset int    `json:"offset"`
+}
+
+// Numeric parameters are taken as strings and parsed in the logic layer,
+// so out-of-range or malformed values get api-gin's defaults instead of
+// go-zero's generic 400.
+
+type CountRequest struct {
+	Count string `form:"count,optional"`
+}
+
+type ListUsersRequest struct {
+	Limit  string `form:"limit,optional"`
+	Offset string `form:"offset,optional"`
+}
+
+type UserPathRequest struct {
+	Id string `path:"id"`
+}
+
+type CreateUserRequest struct {
+	Name  string `json:"name,optional"`
+	Email string `json:"email,optional"`
+	Age   *int   `json:"age,optional"`
+}
+
+type UpdateUserRequest struct {
+	Id    string  `path:"id"`
+	Name  *string `json:"name,optional"`
+	Email *string `json:"email,optional"`
+	Age   *int    `json:"age,optional"`
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/zeromicro/go-zero/core/conf"
+	"github.com/zeromicro/go-zero/rest"
+
+	"api-go-zero/internal/config"
+	"api-go-zero/internal/errorx"
+	"api-go-zero/internal/handler"
+	"api-go-zero/internal/svc"
+
+	_ "go.uber.org/automaxprocs" // GOMAXPROCS from the container CPU quota
+
+	"tlsconfig"
+	"tuning"
+)
+
+var configFile = flag.String("f", "etc/users.yaml", "the config file")
+
+func main() {
+	if err := tuning.Apply(); err != nil {
+		log.Fatalf("runtime tuning: %v", err)
+	}
+	tuning.LogSettings()
+
+	flag.Parse()
+
+	var c config.Config
+	conf.MustLoad(*configFile, &c)
+
+	// PORT and DATABASE_URL override the YAML so docker-compose configures
+	// this service like every other one.
+	if v := os.Getenv("PORT"); v != "" {
+		port, err := strconv.Atoi(v)
+		if err != nil {
+			log.Fatalf("invalid PORT %q: %v", v, err)
+		}
+		c.Port = port
+	}
+	if v := os.Getenv("DATABASE_URL"); v != "" {
+		c.DataSource = v
+	}
+
+	tlsConfig, err := tlsconfig.FromEnv()
+	if err != nil {
+		log.Fatalf("tls: %v", err)
+	}
+	var opts []rest.RunOption
+	if tlsConfig != nil {
+		// go-zero only serves TLS with certificate files configured and loads
+		// that pair too; the reloading GetCertificate still takes precedence
+		// whenever the client sends SNI.
+		c.CertFile, c.KeyFile = os.Getenv("TLS_CERT"), os.Getenv("TLS_KEY")
+		opts = append(opts, rest.WithTLSConfig(tlsConfig))
+	}
+
+	// Router misses answer with the JSON envelope instead of go-zero's
+	// plain-text 404 and empty 405. The 405 handler reads the routes at
+	// request time, once they are registered.
+	var server *rest.Server
+	opts = append(opts,
+		rest.WithNotFoundHandler(http.HandlerFunc(handleNotFound)),
+		rest.WithNotAllowedHandler(handleNotAllowed(func() []rest.Route { return server.Routes() })),
+	)
+	server = rest.MustNewServer(c.RestConf, opts...)
+	defer server.Stop()
+
+	ctx := svc.NewServiceContext(c)
+	defer ctx.DB.Close()
+	handler.RegisterHandlers(server, ctx)
+
+	// go-zero traps SIGTERM itself and drains in-flight requests before
+	// Start returns.
+	log.Printf("Go-zero API listening on %s://%s:%d", tlsconfig.Scheme(tlsConfig), c.Host, c.Port)
+	server.Start()
+}
+
+// handleNotFound answers unregistered paths with the JSON error envelope.
+func handleNotFound(w http.ResponseWriter, r *http.Request) {
+	errorx.Write(w, r, errorx.New(http.StatusNotFound, "Not found"))
+}
+
+// handleNotAllowed answers a known path hit with the wrong method. go-zero
+// drops its Allow header once a custom handler is set, so the path is
+// matched against the registered routes, which only costs on a miss.
+func handleNotAllowed(routes func() []rest.Route) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		reqPath := path.Clean(r.URL.Path)
+		var allowed []string
+		for _, route := range routes() {
+			if matchRoute(route.Path, reqPath) {
+				allowed = append(allowed, route.Method)
+			}
+		}
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		errorx.Write(w, r, errorx.New(http.StatusMethodNotAllowed, "Method not allowed"))
+	}
+}
+
+// matchRoute reports whether reqPath fits a route pattern whose ":name"
+// segments match any single non-empty segment.
+func matchRoute(pattern, reqPath string) bool {
+	ps, segs := strings.Split(pattern, "/"), strings.Split(reqPath, "/")
+	if len(ps) != len(segs) {
+		return false
+	}
+	for i, p := range ps {
+		if p != segs[i] && (!strings.HasPrefix(p, ":") || segs[i] == "") {
+			return false
+		}
+	}
+	return true
+}
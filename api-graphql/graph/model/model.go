@@ -0,0 +1,21 @@
+// Package model holds the Go types bound to the GraphQL schema.
+package model
+
+import "time"
+
+// User represents a row in the users table.
+type User struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Age       *int      `json:"age"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// UserPage is one page of users plus the total row count.
+type UserPage struct {
+	Data   []*User `json:"data"`
+	Total  int    `json:"total"`
+	Limit  int    `json:"limit"`
+	Offset int    `json:"offset"`
+}
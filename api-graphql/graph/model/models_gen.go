@@ -0,0 +1,21 @@
+// Code generated by github.com/99designs/gqlgen, DO NOT EDIT.
+
+package model
+
+type CreateUserInput struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Age   *int   `json:"age,omitempty"`
+}
+
+type Mutation struct {
+}
+
+type Query struct {
+}
+
+type UpdateUserInput struct {
+	Name  *string `json:"name,omitempty"`
+	Email *string `json:"email,omitempty"`
+	Age   *int    `json:"age,omitempty"`
+}
@@ -0,0 +1,10 @@
+package graph
+
+//go:generate go run github.com/99designs/gqlgen generate
+
+import "database/sql"
+
+// Resolver is the root resolver; it only carries the shared pool.
+type Resolver struct {
+	DB *sql.DB
+}
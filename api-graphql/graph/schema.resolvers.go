@@ -0,0 +1,127 @@
+package graph
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.49
+
+import (
+	"api-graphql/graph/model"
+	"context"
+)
+
+// CreateUser is the resolver for the createUser field.
+func (r *mutationResolver) CreateUser(ctx context.Context, input model.CreateUserInput) (*model.User, error) {
+	if input.Name == "" || input.Email == "" {
+		return nil, gqlError(ctx, codeBadUserInput, "name and email are required", "")
+	}
+	row := r.DB.QueryRowContext(ctx, queryCreateUser, input.Name, input.Email, input.Age)
+	user, err := scanUser(row.Scan)
+	if err != nil {
+		return nil, dbError(ctx, err, "User not found")
+	}
+	return user, nil
+}
+
+// UpdateUser is the resolver for the updateUser field.
+func (r *mutationResolver) UpdateUser(ctx context.Context, id int, input model.UpdateUserInput) (*model.User, error) {
+	if id < 1 {
+		return nil, gqlError(ctx, codeBadUserInput, "Invalid user ID", "")
+	}
+	if input.Name == nil && input.Email == nil && input.Age == nil {
+		return nil, gqlError(ctx, codeBadUserInput, "At least one field (name, email, age) is required", "")
+	}
+	row := r.DB.QueryRowContext(ctx, queryUpdateUser, input.Name, input.Email, input.Age, id)
+	user, err := scanUser(row.Scan)
+	if err != nil {
+		return nil, dbError(ctx, err, "User not found")
+	}
+	return user, nil
+}
+
+// DeleteUser is the resolver for the deleteUser field.
+func (r *mutationResolver) DeleteUser(ctx context.Context, id int) (bool, error) {
+	if id < 1 {
+		return false, gqlError(ctx, codeBadUserInput, "Invalid user ID", "")
+	}
+	var deletedID int
+	if err := r.DB.QueryRowContext(ctx, queryDeleteUser, id).Scan(&deletedID); err != nil {
+		return false, dbError(ctx, err, "User not found")
+	}
+	return true, nil
+}
+
+// User is the resolver for the user field.
+func (r *queryResolver) User(ctx context.Context, id int) (*model.User, error) {
+	if id < 1 {
+		return nil, gqlError(ctx, codeBadUserInput, "Invalid user ID", "")
+	}
+	user, err := scanUser(r.DB.QueryRowContext(ctx, queryGetUser, id).Scan)
+	if err != nil {
+		return nil, dbError(ctx, err, "User not found")
+	}
+	return user, nil
+}
+
+// Users is the resolver for the users field.
+func (r *queryResolver) Users(ctx context.Context, limit *int, offset *int) (*model.UserPage, error) {
+	page := &model.UserPage{Limit: 20}
+	if limit != nil {
+		page.Limit = min(max(*limit, 1), 100)
+	}
+	if offset != nil {
+		page.Offset = max(*offset, 0)
+	}
+
+	// COUNT runs concurrently with the page query, as in the REST handlers.
+	countErr := make(chan error, 1)
+	go func() {
+		countErr <- r.DB.QueryRowContext(ctx, queryCountUsers).Scan(&page.Total)
+	}()
+
+	rows, err := r.DB.QueryContext(ctx, queryPageUsers, page.Limit, page.Offset)
+	if err == nil {
+		page.Data, err = collectUsers(rows, page.Limit)
+	}
+	if cerr := <-countErr; cerr != nil {
+		return nil, dbError(ctx, cerr, "No users found")
+	}
+	if err != nil {
+		return nil, dbError(ctx, err, "No users found")
+	}
+	return page, nil
+}
+
+// RandomUser is the resolver for the randomUser field.
+func (r *queryResolver) RandomUser(ctx context.Context) (*model.User, error) {
+	user, err := scanUser(r.DB.QueryRowContext(ctx, queryRandomUser).Scan)
+	if err != nil {
+		return nil, dbError(ctx, err, "No users found")
+	}
+	return user, nil
+}
+
+// RandomUsers is the resolver for the randomUsers field.
+func (r *queryResolver) RandomUsers(ctx context.Context, count *int) ([]*model.User, error) {
+	n := 1
+	if count != nil && *count > 1 {
+		n = min(*count, 500)
+	}
+	rows, err := r.DB.QueryContext(ctx, queryRandomUsers, n)
+	if err != nil {
+		return nil, dbError(ctx, err, "No users found")
+	}
+	users, err := collectUsers(rows, n)
+	if err != nil {
+		return nil, dbError(ctx, err, "No users found")
+	}
+	return users, nil
+}
+
+// Mutation returns MutationResolver implementation.
+func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
+
+// Query returns QueryResolver implementation.
+func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
+
+type mutationResolver struct{ *Resolver }
+type queryResolver struct{ *Resolver }
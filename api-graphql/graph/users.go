@@ -0,0 +1,100 @@
+package graph
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	"api-graphql/graph/model"
+)
+
+// Same SQL as the REST implementations, so the only difference measured is
+// the GraphQL layer.
+const (
+	queryRandomUser  = `SELECT id, name, email, age, created_at FROM users ORDER BY RANDOM() LIMIT 1`
+	queryRandomUsers = `SELECT id, name, email, age, created_at FROM users ORDER BY RANDOM() LIMIT $1`
+	queryPageUsers   = `SELECT id, name, email, age, created_at FROM users ORDER BY id LIMIT $1 OFFSET $2`
+	queryCountUsers  = `SELECT COUNT(*)::int FROM users`
+	queryGetUser     = `SELECT id, name, email, age, created_at FROM users WHERE id = $1`
+	queryCreateUser  = `
+		INSERT INTO users (name, email, age)
+		VALUES ($1, $2, $3)
+		RETURNING id, name, email, age, created_at`
+	// COALESCE updates only the provided fields, the same SQL as api-gin.
+	queryUpdateUser = `
+		UPDATE users
+		SET name  = COALESCE($1, name),
+		    email = COALESCE($2, email),
+		    age   = COALESCE($3, age)
+		WHERE id = $4
+		RETURNING id, name, email, age, created_at`
+	queryDeleteUser = `DELETE FROM users WHERE id = $1 RETURNING id`
+)
+
+// Error codes reported in extensions.code; each stands in for the HTTP
+// status the REST implementations answer with.
+const (
+	codeBadUserInput = "BAD_USER_INPUT"        // 400
+	codeNotFound     = "NOT_FOUND"             // 404
+	codeConflict     = "CONFLICT"              // 409
+	codeInternal     = "INTERNAL_SERVER_ERROR" // 500
+)
+
+// scanUser reads a single User from any *sql.Row / *sql.Rows via the scan func.
+func scanUser(scan func(...any) error) (*model.User, error) {
+	var u model.User
+	if err := scan(&u.ID, &u.Name, &u.Email, &u.Age, &u.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// collectUsers drains rows into a slice; sizeHint preallocates it.
+func collectUsers(rows *sql.Rows, sizeHint int) ([]*model.User, error) {
+	defer rows.Close()
+	users := make([]*model.User, 0, sizeHint)
+	for rows.Next() {
+		user, err := scanUser(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+// gqlError builds an error for the current field with code and, when not
+// empty, detail in its extensions.
+func gqlError(ctx context.Context, code, msg, detail string) error {
+	ext := map[string]any{"code": code}
+	if detail != "" {
+		ext["detail"] = detail
+	}
+	return &gqlerror.Error{
+		Path:       graphql.GetPath(ctx),
+		Message:    msg,
+		Extensions: ext,
+	}
+}
+
+// dbError maps a query error onto a GraphQL error: sql.ErrNoRows is
+// notFound, a duplicate email CONFLICT, anything else INTERNAL_SERVER_ERROR.
+func dbError(ctx context.Context, err error, notFound string) error {
+	switch {
+	case err == sql.ErrNoRows:
+		return gqlError(ctx, codeNotFound, notFound, "")
+	case isUniqueViolation(err):
+		return gqlError(ctx, codeConflict, "Email already in use", "")
+	default:
+		return gqlError(ctx, codeInternal, "Database error", err.Error())
+	}
+}
+
+// isUniqueViolation reports a PostgreSQL unique_violation (SQLSTATE 23505).
+func isUniqueViolation(err error) bool {
+	type hasSQLState interface{ SQLState() string }
+	e, ok := err.(hasSQLState)
+	return ok && e.SQLState() == "23505"
+}
@@ -0,0 +1,6 @@
+//go:build tools
+
+package main
+
+// Keeps the gqlgen generator in go.mod for `go generate ./...`.
+import _ "github.com/99designs/gqlgen"
@@ -0,0 +1,23 @@
+module api-grpc
+
+go 1.22
+
+require (
+	github.com/lib/pq v1.10.9
+	go.uber.org/automaxprocs v1.6.0
+	google.golang.org/grpc v1.64.0
+	google.golang.org/protobuf v1.34.2
+	tlsconfig v0.0.0-00010101000000-000000000000
+	tuning v0.0.0-00010101000000-000000000000
+)
+
+require (
+	golang.org/x/net v0.22.0 // indirect
+	golang.org/x/sys v0.18.0 // indirect
+	golang.org/x/text v0.14.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240318140521-94a12d6c2237 // indirect
+)
+
+replace tlsconfig => ../tlsconfig
+
+replace tuning => ../tuning
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"api-grpc/userpb"
+)
+
+// ---------------------------------------------------------------------------
+// UserService
+// ---------------------------------------------------------------------------
+
+// Same SQL as the REST implementations, so the only difference measured is
+// the transport and encoding.
+const (
+	queryRandomUser = `SELECT id, name, email, age, created_at FROM users ORDER BY RANDOM() LIMIT 1`
+	queryListUsers  = `SELECT id, name, email, age, created_at FROM users ORDER BY id`
+	queryPageUsers  = `SELECT id, name, email, age, created_at FROM users ORDER BY id LIMIT $1 OFFSET $2`
+	queryCountUsers = `SELECT COUNT(*)::int FROM users`
+	queryGetUser    = `SELECT id, name, email, age, created_at FROM users WHERE id = $1`
+	queryCreateUser = `
+		INSERT INTO users (name, email, age)
+		VALUES ($1, $2, $3)
+		RETURNING id, name, email, age, created_at`
+	// COALESCE updates only the provided fields, the same SQL as api-gin.
+	queryUpdateUser = `
+		UPDATE users
+		SET name  = COALESCE($1, name),
+		    email = COALESCE($2, email),
+		    age   = COALESCE($3, age)
+		WHERE id = $4
+		RETURNING id, name, email, age, created_at`
+	queryDeleteUser = `DELETE FROM users WHERE id = $1 RETURNING id`
+)
+
+type userService struct {
+	userpb.UnimplementedUserServiceServer
+	db *sql.DB
+}
+
+// scanUser reads one row straight into the protobuf message.
+func scanUser(scan func(...any) error) (*userpb.User, error) {
+	var (
+		u         userpb.User
+		email     string
+		age       sql.NullInt32
+		createdAt time.Time
+	)
+	if err := scan(&u.Id, &u.Name, &email, &age, &createdAt); err != nil {
+		return nil, err
+	}
+	u.Email = &email
+	if age.Valid {
+		u.Age = &age.Int32
+	}
+	u.CreatedAt = timestamppb.New(createdAt)
+	return &u, nil
+}
+
+// grpcError maps a query error onto a status: sql.ErrNoRows is notFound,
+// a duplicate email AlreadyExists, anything else Internal.
+func grpcError(err error, notFound string) error {
+	switch {
+	case err == sql.ErrNoRows:
+		return status.Error(codes.NotFound, notFound)
+	case isUniqueViolation(err):
+		return status.Error(codes.AlreadyExists, "Email already in use")
+	default:
+		return status.Errorf(codes.Internal, "Database error: %v", err)
+	}
+}
+
+// isUniqueViolation reports a PostgreSQL unique_violation (SQLSTATE 23505).
+func isUniqueViolation(err error) bool {
+	type hasSQLState interface{ SQLState() string }
+	e, ok := err.(hasSQLState)
+	return ok && e.SQLState() == "23505"
+}
+
+func (s *userService) RandomUser(ctx context.Context, _ *emptypb.Empty) (*userpb.User, error) {
+	user, err := scanUser(s.db.QueryRowContext(ctx, queryRandomUser).Scan)
+	if err != nil {
+		return nil, grpcError(err, "No users found")
+	}
+	return user, nil
+}
+
+func (s *userService) GetUser(ctx context.Context, req *userpb.GetUserRequest) (*userpb.User, error) {
+	if req.GetId() < 1 {
+		return nil, status.Error(codes.InvalidArgument, "Invalid user ID")
+	}
+	user, err := scanUser(s.db.QueryRowContext(ctx, queryGetUser, req.GetId()).Scan)
+	if err != nil {
+		return nil, grpcError(err, "User not found")
+	}
+	return user, nil
+}
+
+// ListUsers returns every user when limit is 0, otherwise one page (limit
+// clamped to 1-100) with the total, COUNT and SELECT running concurrently
+// as in the REST handlers.
+func (s *userService) ListUsers(ctx context.Context, req *userpb.ListUsersRequest) (*userpb.ListUsersResponse, error) {
+	limit := int(req.GetLimit())
+	offset := max(int(req.GetOffset()), 0)
+
+	if limit == 0 {
+		rows, err := s.db.QueryContext(ctx, queryListUsers)
+		if err != nil {
+			return nil, grpcError(err, "No users found")
+		}
+		users, err := collectUsers(rows, 0)
+		if err != nil {
+			return nil, grpcError(err, "No users found")
+		}
+		return &userpb.ListUsersResponse{Users: users}, nil
+	}
+	limit = min(max(limit, 1), 100)
+
+	type countResult struct {
+		total int32
+		err   error
+	}
+	countCh := make(chan countResult, 1)
+	go func() {
+		var total int32
+		err := s.db.QueryRowContext(ctx, queryCountUsers).Scan(&total)
+		countCh <- countResult{total, err}
+	}()
+
+	var users []*userpb.User
+	rows, err := s.db.QueryContext(ctx, queryPageUsers, limit, offset)
+	if err == nil {
+		users, err = collectUsers(rows, limit)
+	}
+	cr := <-countCh
+	if cr.err != nil {
+		return nil, grpcError(cr.err, "No users found")
+	}
+	if err != nil {
+		return nil, grpcError(err, "No users found")
+	}
+	return &userpb.ListUsersResponse{Users: users, Total: cr.total}, nil
+}
+
+// collectUsers drains rows into a slice; sizeHint preallocates it.
+func collectUsers(rows *sql.Rows, sizeHint int) ([]*userpb.User, error) {
+	defer rows.Close()
+	users := make([]*userpb.User, 0, sizeHint)
+	for rows.Next() {
+		user, err := scanUser(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+func (s *userService) CreateUser(ctx context.Context, req *userpb.CreateUserRequest) (*userpb.User, error) {
+	if req.GetName() == "" || req.GetEmail() == "" {
+		return nil, status.Error(codes.InvalidArgument, "name and email are required")
+	}
+	row := s.db.QueryRowContext(ctx, queryCreateUser, req.GetName(), req.GetEmail(), req.Age)
+	user, err := scanUser(row.Scan)
+	if err != nil {
+		return nil, grpcError(err, "User not found")
+	}
+	return user, nil
+}
+
+func (s *userService) UpdateUser(ctx context.Context, req *userpb.UpdateUserRequest) (*userpb.User, error) {
+	if req.GetId() < 1 {
+		return nil, status.Error(codes.InvalidArgument, "Invalid user ID")
+	}
+	if req.Name == nil && req.Email == nil && req.Age == nil {
+		return nil, status.Error(codes.InvalidArgument, "At least one field (name, email, age) is required")
+	}
+	row := s.db.QueryRowContext(ctx, queryUpdateUser, req.Name, req.Email, req.Age, req.GetId())
+	user, err := scanUser(row.Scan)
+	if err != nil {
+		return nil, grpcError(err, "User not found")
+	}
+	return user, nil
+}
+
+func (s *userService) DeleteUser(ctx context.Context, req *userpb.DeleteUserRequest) (*emptypb.Empty, error) {
+	if req.GetId() < 1 {
+		return nil, status.Error(codes.InvalidArgument, "Invalid user ID")
+	}
+	var deletedID int32
+	if err := s.db.QueryRowContext(ctx, queryDeleteUser, req.GetId()).Scan(&deletedID); err != nil {
+		return nil, grpcError(err, "User not found")
+	}
+	return &emptypb.Empty{}, nil
+}
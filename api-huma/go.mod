@@ -0,0 +1,19 @@
+module api-huma
+
+go 1.22
+
+require (
+	github.com/danielgtaylor/huma/v2 v2.22.1
+	github.com/lib/pq v1.10.9
+	problem v0.0.0-00010101000000-000000000000
+	tlsconfig v0.0.0-00010101000000-000000000000
+	tuning v0.0.0-00010101000000-000000000000
+)
+
+require go.uber.org/automaxprocs v1.6.0
+
+replace problem => ../problem
+
+replace tlsconfig => ../tlsconfig
+
+replace tuning => ../tuning
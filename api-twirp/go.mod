@@ -0,0 +1,21 @@
+module api-twirp
+
+go 1.22
+
+require (
+	github.com/lib/pq v1.10.9
+	github.com/twitchtv/twirp v8.1.3+incompatible
+	go.uber.org/automaxprocs v1.6.0
+	google.golang.org/protobuf v1.34.2
+	problem v0.0.0-00010101000000-000000000000
+	tlsconfig v0.0.0-00010101000000-000000000000
+	tuning v0.0.0-00010101000000-000000000000
+)
+
+require github.com/pkg/errors v0.9.1 // indirect
+
+replace problem => ../problem
+
+replace tlsconfig => ../tlsconfig
+
+replace tuning => ../tuning
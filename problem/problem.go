@@ -0,0 +1,53 @@
+// Package problem is the error envelope of every Go implementation: RFC 7807
+// problem details, served as application/problem+json.
+package problem
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// ContentType is the media type of a problem details body.
+const ContentType = "application/problem+json"
+
+// Details is a problem details object. Type is always "about:blank": the
+// status code is the whole classification, so Title is its reason phrase
+// (RFC 7807 section 4.2) and Detail carries the message for this occurrence.
+// Services add extension members by embedding Details in a larger struct.
+type Details struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+}
+
+// New returns the problem for a response with status to the request for
+// instance, normally its path. A non-empty cause, such as a driver error, is
+// appended to detail.
+func New(status int, instance, detail, cause string) Details {
+	if cause != "" {
+		detail += ": " + cause
+	}
+	return Details{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: instance,
+	}
+}
+
+// JSON returns d encoded. Details holds only strings and an int, so encoding
+// cannot fail.
+func (d Details) JSON() []byte {
+	b, _ := json.Marshal(d)
+	return b
+}
+
+// Write sends d as the response on w, with d.Status as the status code.
+func Write(w http.ResponseWriter, d Details) {
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(d.Status)
+	w.Write(d.JSON())
+}
@@ -61,7 +61,7 @@ except ImportError:
 # AWS t3.medium on-demand (us-east-1, 2 vCPU, 4 GB RAM) — Jan 2025
 AWS_T3_MEDIUM_USD_PER_HOUR = 0.0416
 
-FRAMEWORKS = ['express', 'fastify', 'elysia', 'actix', 'gin']
+FRAMEWORKS = ['express', 'fastify', 'elysia', 'actix', 'gin', 'echo', 'fiber', 'chi', 'stdlib', 'fasthttp', 'hertz', 'gorilla', 'go-zero', 'iris', 'beego', 'httprouter', 'huma', 'gin-gorm', 'gin-sqlc', 'gin-sqlx', 'gin-ent', 'gin-bun', 'echo-pgx', 'atreugo']
 
 FRAMEWORK_LABELS = {
     'express': 'Express\n(Node.js)',
@@ -69,6 +69,25 @@ FRAMEWORK_LABELS = {
     'elysia':  'Elysia\n(Bun)',
     'actix':   'Actix-web\n(Rust)',
     'gin':     'Gin\n(Go)',
+    'echo':    'Echo\n(Go)',
+    'fiber':   'Fiber\n(Go)',
+    'chi':     'Chi\n(Go)',
+    'stdlib':  'net/http\n(Go)',
+    'fasthttp': 'fasthttp\n(Go)',
+    'hertz':   'Hertz\n(Go)',
+    'gorilla': 'gorilla/mux\n(Go)',
+    'go-zero': 'go-zero\n(Go)',
+    'iris':    'Iris\n(Go)',
+    'beego':   'Beego\n(Go)',
+    'httprouter': 'httprouter\n(Go)',
+    'huma':    'Huma\n(Go)',
+    'gin-gorm': 'Gin + GORM\n(Go)',
+    'gin-sqlc': 'Gin + sqlc\n(Go)',
+    'gin-sqlx': 'Gin + sqlx\n(Go)',
+    'gin-ent': 'Gin + ent\n(Go)',
+    'gin-bun': 'Gin + Bun\n(Go)',
+    'echo-pgx': 'Echo + pgx\n(Go)',
+    'atreugo': 'Atreugo\n(Go)',
 }
 
 # ---------------------------------------------------------------------------
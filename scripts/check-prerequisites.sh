@@ -129,8 +129,8 @@ fi
 echo ""
 
 # --- Portas ---
-echo "--- Portas de rede (3001-3005, 5432) ---"
-PORTS=(3001 3002 3003 3004 3005 5432)
+echo "--- Portas de rede (3001-3029, 5432) ---"
+PORTS=(3001 3002 3003 3004 3005 3006 3007 3008 3009 3010 3011 3012 3013 3014 3015 3016 3017 3018 3019 3020 3021 3022 3023 3024 3025 3026 3027 3028 3029 5432)
 for PORT in "${PORTS[@]}"; do
   if ss -tlnp 2>/dev/null | grep -q ":$PORT " || netstat -tlnp 2>/dev/null | grep -q ":$PORT "; then
     warn "Porta $PORT já está em uso — pode conflitar com os containers"
@@ -0,0 +1,14 @@
+#!/usr/bin/env bash
+# gen-certs.sh — gera certificado autoassinado (ECDSA P-256) em ./certs para os
+# testes com TLS/HTTP/3. Uso: ./scripts/gen-certs.sh [host] (padrão: localhost)
+set -euo pipefail
+
+HOST="${1:-localhost}"
+DIR="$(cd "$(dirname "$0")/.." && pwd)/certs"
+
+mkdir -p "$DIR"
+openssl req -x509 -newkey ec -pkeyopt ec_paramgen_curve:prime256v1 -nodes \
+  -keyout "$DIR/key.pem" -out "$DIR/cert.pem" -days 365 \
+  -subj "/CN=$HOST" -addext "subjectAltName=DNS:$HOST,DNS:localhost,IP:127.0.0.1"
+
+echo "certificado gerado em $DIR (cert.pem, key.pem) para $HOST"
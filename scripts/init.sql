@@ -6,7 +6,9 @@ CREATE TABLE IF NOT EXISTS users (
     name       VARCHAR(255) NOT NULL,
     email      VARCHAR(255) NOT NULL UNIQUE,
     age        INTEGER,
-    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    -- Versão da linha para concorrência otimista (ETag / If-Match no Gin)
+    version    INTEGER NOT NULL DEFAULT 1
 );
 
 -- Seed: 10.000 registros (alinhado com TechEmpower Framework Benchmarks)
@@ -5,6 +5,8 @@
 set -e
 
 RESULTS_DIR="./results/$(date +%Y%m%d_%H%M%S)"
+# SCHEME=https para APIs com TLS_CERT (certificado autoassinado aceito)
+SCHEME="${SCHEME:-http}"
 mkdir -p "$RESULTS_DIR"
 
 APIS=(
@@ -13,6 +15,25 @@ APIS=(
   "elysia:3003"
   "actix:3004"
   "gin:3005"
+  "echo:3006"
+  "fiber:3007"
+  "chi:3008"
+  "stdlib:3009"
+  "fasthttp:3010"
+  "hertz:3011"
+  "gorilla:3012"
+  "go-zero:3013"
+  "iris:3014"
+  "beego:3015"
+  "httprouter:3016"
+  "huma:3022"
+  "gin-gorm:3023"
+  "gin-sqlc:3024"
+  "gin-sqlx:3025"
+  "gin-ent:3026"
+  "gin-bun:3027"
+  "echo-pgx:3028"
+  "atreugo:3029"
 )
 
 echo "======================================"
@@ -23,14 +44,14 @@ echo "======================================"
 for api_port in "${APIS[@]}"; do
   API_NAME="${api_port%%:*}"
   PORT="${api_port##*:}"
-  BASE_URL="http://localhost:${PORT}"
+  BASE_URL="${SCHEME}://localhost:${PORT}"
 
   echo ""
   echo ">>> Testando: ${API_NAME} em ${BASE_URL}"
 
   # Aguarda API estar pronta
   for i in $(seq 1 30); do
-    if curl -sf "${BASE_URL}/" > /dev/null 2>&1; then
+    if curl -skf "${BASE_URL}/" > /dev/null 2>&1; then
       echo "    API pronta."
       break
     fi
@@ -40,6 +61,7 @@ for api_port in "${APIS[@]}"; do
 
   # Executa k6
   k6 run \
+    --insecure-skip-tls-verify \
     --env BASE_URL="${BASE_URL}" \
     --out json="${RESULTS_DIR}/${API_NAME}.json" \
     --summary-export="${RESULTS_DIR}/${API_NAME}_summary.json" \
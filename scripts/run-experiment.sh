@@ -108,6 +108,25 @@ declare -A FRAMEWORK_PORTS=(
   [elysia]=3003
   [actix]=3004
   [gin]=3005
+  [echo]=3006
+  [fiber]=3007
+  [chi]=3008
+  [stdlib]=3009
+  [fasthttp]=3010
+  [hertz]=3011
+  [gorilla]=3012
+  [go-zero]=3013
+  [iris]=3014
+  [beego]=3015
+  [httprouter]=3016
+  [huma]=3022
+  [gin-gorm]=3023
+  [gin-sqlc]=3024
+  [gin-sqlx]=3025
+  [gin-ent]=3026
+  [gin-bun]=3027
+  [echo-pgx]=3028
+  [atreugo]=3029
 )
 
 declare -A FRAMEWORK_SERVICES=(
@@ -116,9 +135,28 @@ declare -A FRAMEWORK_SERVICES=(
   [elysia]=api-elysia
   [actix]=api-actix
   [gin]=api-gin
+  [echo]=api-echo
+  [fiber]=api-fiber
+  [chi]=api-chi
+  [stdlib]=api-stdlib
+  [fasthttp]=api-fasthttp
+  [hertz]=api-hertz
+  [gorilla]=api-gorilla
+  [go-zero]=api-go-zero
+  [iris]=api-iris
+  [beego]=api-beego
+  [httprouter]=api-httprouter
+  [huma]=api-huma
+  [gin-gorm]=api-gin-gorm
+  [gin-sqlc]=api-gin-sqlc
+  [gin-sqlx]=api-gin-sqlx
+  [gin-ent]=api-gin-ent
+  [gin-bun]=api-gin-bun
+  [echo-pgx]=api-echo-pgx
+  [atreugo]=api-atreugo
 )
 
-FRAMEWORK_ORDER=(express fastify elysia actix gin)
+FRAMEWORK_ORDER=(express fastify elysia actix gin echo fiber chi stdlib fasthttp hertz gorilla go-zero iris beego httprouter huma gin-gorm gin-sqlc gin-sqlx gin-ent gin-bun echo-pgx atreugo)
 
 # Array para guardar max sustentável de cada framework
 declare -A FRAMEWORK_MAX_RPS=()
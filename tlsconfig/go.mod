@@ -0,0 +1,3 @@
+module tlsconfig
+
+go 1.22
@@ -0,0 +1,100 @@
+// Package tlsconfig is the TLS setup of every Go implementation (TLS_CERT,
+// TLS_KEY): the server config, with the key pair reloaded on SIGHUP. Only
+// serving a listener over the config stays per framework.
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+)
+
+// certReloader serves the key pair in certFile and keyFile and re-reads it
+// on SIGHUP, so a renewed certificate is picked up without a restart: new
+// handshakes get the new pair while open connections keep theirs. A reload
+// that fails is logged and the previous pair stays in use.
+type certReloader struct {
+	certFile, keyFile string
+	cert              atomic.Pointer[tls.Certificate]
+}
+
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			if err := r.reload(); err != nil {
+				log.Printf("tls: reload failed, keeping the current certificate: %v", err)
+				continue
+			}
+			log.Printf("tls: certificate reloaded from %s", r.certFile)
+		}
+	}()
+	return r, nil
+}
+
+func (r *certReloader) reload() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+	r.cert.Store(&cert)
+	return nil
+}
+
+func (r *certReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.cert.Load(), nil
+}
+
+// Load returns the server TLS config for the key pair in certFile and
+// keyFile, reloaded on SIGHUP.
+func Load(certFile, keyFile string) (*tls.Config, error) {
+	r, err := newCertReloader(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: r.getCertificate,
+	}, nil
+}
+
+// FromEnv returns the server TLS config for TLS_CERT and TLS_KEY, or nil
+// when neither is set and the API serves plain HTTP.
+func FromEnv() (*tls.Config, error) {
+	certFile, keyFile := os.Getenv("TLS_CERT"), os.Getenv("TLS_KEY")
+	if certFile == "" && keyFile == "" {
+		return nil, nil
+	}
+	if certFile == "" || keyFile == "" {
+		return nil, errors.New("TLS_CERT and TLS_KEY must be set together")
+	}
+	return Load(certFile, keyFile)
+}
+
+// Scheme is the URL scheme logged at startup.
+func Scheme(c *tls.Config) string {
+	if c != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// ListenAndServe serves srv over TLS when it has a TLSConfig, with HTTP/2
+// negotiated through ALPN, and over plain HTTP/1.1 otherwise.
+func ListenAndServe(srv *http.Server) error {
+	if srv.TLSConfig != nil {
+		return srv.ListenAndServeTLS("", "")
+	}
+	return srv.ListenAndServe()
+}
@@ -0,0 +1,3 @@
+module tuning
+
+go 1.22
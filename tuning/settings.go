@@ -0,0 +1,99 @@
+package tuning
+
+import (
+	"log"
+	"math"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+)
+
+// Settings is the scheduler and GC configuration a process runs with, after
+// automaxprocs and Apply. Every service imports go.uber.org/automaxprocs,
+// which lowers GOMAXPROCS to the container CPU quota before main runs;
+// without it the scheduler sizes itself to the host's cores and
+// over-schedules under a docker cpus limit.
+type Settings struct {
+	GOMAXPROCS int
+	NumCPU     int
+	CPUQuota   float64 // cores; 0 without a cgroup limit
+	GOGC       int     // -1 when the collector is off
+	GOMemLimit int64   // bytes; 0 when unlimited
+	Ballast    int64   // bytes
+}
+
+// Current reads the effective Settings.
+func Current() Settings {
+	return Settings{
+		GOMAXPROCS: runtime.GOMAXPROCS(0),
+		NumCPU:     runtime.NumCPU(),
+		CPUQuota:   cpuQuota(),
+		GOGC:       gcPercent(),
+		GOMemLimit: memoryLimit(),
+		Ballast:    Ballast(),
+	}
+}
+
+// LogSettings records the Settings the benchmark actually ran with.
+func LogSettings() {
+	s := Current()
+	quota := "none"
+	if s.CPUQuota > 0 {
+		quota = strconv.FormatFloat(s.CPUQuota, 'f', 2, 64)
+	}
+	gogc := "off"
+	if s.GOGC >= 0 {
+		gogc = strconv.Itoa(s.GOGC)
+	}
+	memLimit := "none"
+	if s.GOMemLimit > 0 {
+		memLimit = strconv.FormatInt(s.GOMemLimit, 10)
+	}
+	log.Printf("runtime: GOMAXPROCS=%d NumCPU=%d cpu_quota=%s GOGC=%s GOMEMLIMIT=%s ballast=%d",
+		s.GOMAXPROCS, s.NumCPU, quota, gogc, memLimit, s.Ballast)
+}
+
+// cpuQuota returns the CPU limit of the enclosing cgroup in cores, or 0 when
+// there is none or it cannot be read (cgroup v2 cpu.max, then v1 CFS).
+func cpuQuota() float64 {
+	if raw, err := os.ReadFile("/sys/fs/cgroup/cpu.max"); err == nil {
+		fields := strings.Fields(string(raw))
+		if len(fields) == 2 && fields[0] != "max" {
+			return parseQuota(fields[0], fields[1])
+		}
+		return 0
+	}
+	quota, err1 := os.ReadFile("/sys/fs/cgroup/cpu/cpu.cfs_quota_us")
+	period, err2 := os.ReadFile("/sys/fs/cgroup/cpu/cpu.cfs_period_us")
+	if err1 != nil || err2 != nil {
+		return 0
+	}
+	return parseQuota(strings.TrimSpace(string(quota)), strings.TrimSpace(string(period)))
+}
+
+func parseQuota(quota, period string) float64 {
+	q, err1 := strconv.ParseFloat(quota, 64)
+	p, err2 := strconv.ParseFloat(period, 64)
+	if err1 != nil || err2 != nil || q <= 0 || p <= 0 {
+		return 0
+	}
+	return q / p
+}
+
+// gcPercent returns the effective GOGC, -1 when the collector is off. The
+// runtime only exposes it through SetGCPercent, so it is set back at once.
+func gcPercent() int {
+	p := debug.SetGCPercent(100)
+	debug.SetGCPercent(p)
+	return p
+}
+
+// memoryLimit returns the effective GOMEMLIMIT in bytes, 0 when unlimited.
+func memoryLimit() int64 {
+	if l := debug.SetMemoryLimit(-1); l != math.MaxInt64 {
+		return l
+	}
+	return 0
+}
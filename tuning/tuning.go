@@ -0,0 +1,103 @@
+// Package tuning applies the GC settings of a runtime tuning experiment
+// (BENCH_GOGC, BENCH_GOMEMLIMIT, BENCH_BALLAST) to every Go implementation,
+// so the settings are parsed the same way everywhere, and logs the scheduler
+// and GC configuration each service ends up running with.
+package tuning
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"runtime/debug"
+	"strconv"
+	"strings"
+)
+
+// ballast is a heap allocation that is never read or written. It raises the
+// live heap the GC paces against, so collections run less often; its pages
+// are never touched, so it costs address space rather than resident memory.
+var ballast []byte
+
+// Apply applies BENCH_GOGC ("off" or a percentage), BENCH_GOMEMLIMIT ("off"
+// or a size) and BENCH_BALLAST (a size), and logs what it set. Sizes are
+// written as for GOMEMLIMIT: bytes, optionally with a B, KiB, MiB, GiB or TiB
+// suffix. Unset variables leave the runtime defaults, including GOGC and
+// GOMEMLIMIT, in place.
+func Apply() error {
+	var applied []string
+
+	if v := os.Getenv("BENCH_GOGC"); v != "" {
+		percent := -1
+		if v != "off" {
+			p, err := strconv.Atoi(v)
+			if err != nil || p < 0 {
+				return fmt.Errorf(`BENCH_GOGC must be "off" or a percentage >= 0, got %q`, v)
+			}
+			percent = p
+		}
+		debug.SetGCPercent(percent)
+		applied = append(applied, "GOGC="+v)
+	}
+
+	if v := os.Getenv("BENCH_GOMEMLIMIT"); v != "" {
+		limit := int64(math.MaxInt64)
+		if v != "off" {
+			n, err := parseByteSize(v)
+			if err != nil {
+				return fmt.Errorf("BENCH_GOMEMLIMIT: %w", err)
+			}
+			limit = n
+		}
+		debug.SetMemoryLimit(limit)
+		applied = append(applied, "GOMEMLIMIT="+v)
+	}
+
+	if v := os.Getenv("BENCH_BALLAST"); v != "" {
+		n, err := parseByteSize(v)
+		if err != nil {
+			return fmt.Errorf("BENCH_BALLAST: %w", err)
+		}
+		ballast = make([]byte, n)
+		applied = append(applied, "ballast="+v)
+	}
+
+	if len(applied) > 0 {
+		log.Printf("runtime tuning: %s", strings.Join(applied, " "))
+	}
+	return nil
+}
+
+// Ballast returns the size of the heap ballast in bytes, 0 without one.
+func Ballast() int64 {
+	return int64(len(ballast))
+}
+
+// byteSizeUnits lists the suffixes GOMEMLIMIT accepts, longest first so "B"
+// does not match the end of "MiB".
+var byteSizeUnits = []struct {
+	suffix string
+	scale  int64
+}{
+	{"TiB", 1 << 40},
+	{"GiB", 1 << 30},
+	{"MiB", 1 << 20},
+	{"KiB", 1 << 10},
+	{"B", 1},
+}
+
+// parseByteSize parses a size such as "512MiB" or "1073741824".
+func parseByteSize(s string) (int64, error) {
+	num, scale := s, int64(1)
+	for _, u := range byteSizeUnits {
+		if strings.HasSuffix(s, u.suffix) {
+			num, scale = strings.TrimSuffix(s, u.suffix), u.scale
+			break
+		}
+	}
+	n, err := strconv.ParseInt(num, 10, 64)
+	if err != nil || n < 0 || n > math.MaxInt64/scale {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return n * scale, nil
+}